@@ -0,0 +1,487 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/RLBot/go-interface/flat"
+)
+
+type AgentLogLine struct {
+	Agent  string    `json:"agent"`
+	Stream string    `json:"stream"`
+	Line   string    `json:"line"`
+	Time   time.Time `json:"time"`
+}
+
+// how long lines of agents are collected before they're sent to the frontend
+const agentLogInterval = 100 * time.Millisecond
+
+// agentLogBatch sends the lines of every agent to the frontend together
+// as the "agent-log" event, instead of one event per line
+type agentLogBatch struct {
+	mu    sync.Mutex
+	lines []AgentLogLine
+	// set while a flush is pending
+	timer *time.Timer
+}
+
+func (b *agentLogBatch) add(line AgentLogLine) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lines = append(b.lines, line)
+	if b.timer == nil {
+		b.timer = time.AfterFunc(agentLogInterval, b.flush)
+	}
+}
+
+func (b *agentLogBatch) flush() {
+	b.mu.Lock()
+	lines := b.lines
+	b.lines = nil
+	b.timer = nil
+	b.mu.Unlock()
+
+	if len(lines) > 0 {
+		emitEvent("agent-log", lines)
+	}
+}
+
+type AgentProcess struct {
+	// Unique id of this process within the current match
+	Key     string `json:"key"`
+	Name    string `json:"name"`
+	AgentId string `json:"agentId"`
+	Team    uint32 `json:"team"`
+	// Indices of the players controlled by this process, empty for scripts
+	PlayerIndices []uint32 `json:"playerIndices"`
+	IsScript      bool     `json:"isScript"`
+	RootDir       string   `json:"rootDir"`
+	RunCommand    string   `json:"runCommand"`
+	LogPath       string   `json:"logPath"`
+	Pid           int      `json:"pid"`
+	Running       bool     `json:"running"`
+	ExitCode      int      `json:"exitCode"`
+	// If the agent exited with an error without being asked to
+	Crashed  bool `json:"crashed"`
+	Restarts int  `json:"restarts"`
+	// Restart the agent when its source files change
+	AutoReload bool `json:"autoReload"`
+
+	cmd *exec.Cmd
+	log *RotatingLog
+	// closed once the current run of the process exited
+	done chan struct{}
+	// set when the GUI kills the agent, so the exit isn't treated as a crash
+	stopping bool
+	// closed when stopping is set, to cancel a pending restart
+	stopped chan struct{}
+}
+
+// AgentManager launches bots and scripts itself instead of leaving it to RLBotServer,
+// so that their output can be captured
+type AgentManager struct {
+	mu           sync.Mutex
+	rlbotAddress string
+	logDir       string
+	settings     SupervisorSettings
+	processes    []*AgentProcess
+	// file watchers for auto reloading, by agent key
+	watchers map[string]context.CancelFunc
+	logLines agentLogBatch
+}
+
+func NewAgentManager(rlbotAddress string, logDir string) *AgentManager {
+	return &AgentManager{
+		rlbotAddress: rlbotAddress,
+		logDir:       logDir,
+		settings:     DefaultSupervisorSettings(),
+		watchers:     map[string]context.CancelFunc{},
+	}
+}
+
+func (m *AgentManager) Settings() SupervisorSettings {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.settings
+}
+
+func (m *AgentManager) SetSettings(settings SupervisorSettings) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.settings = settings
+}
+
+// SetRLBotAddress changes the RLBotServer that agents started from now on connect to
+func (m *AgentManager) SetRLBotAddress(rlbotAddress string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rlbotAddress = rlbotAddress
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+func sanitizeFileName(name string) string {
+	return unsafeFileChars.ReplaceAllString(name, "_")
+}
+
+// agentsFromMatch lists the processes needed to run the bots and scripts of a match,
+// with one process per hivemind team
+func agentsFromMatch(match *flat.MatchConfigurationT) []*AgentProcess {
+	agents := []*AgentProcess{}
+	hiveminds := map[string]*AgentProcess{}
+
+	for i, player := range match.PlayerConfigurations {
+		if player.Variety == nil {
+			continue
+		}
+
+		bot, ok := player.Variety.Value.(*flat.CustomBotT)
+		if !ok || bot.RunCommand == "" {
+			continue
+		}
+
+		index := uint32(i)
+		hivemindKey := fmt.Sprintf("%s-%d", bot.AgentId, player.Team)
+		if existing, ok := hiveminds[hivemindKey]; ok && bot.Hivemind {
+			existing.PlayerIndices = append(existing.PlayerIndices, index)
+			continue
+		}
+
+		agent := &AgentProcess{
+			Key:           fmt.Sprintf("%s-%d", bot.AgentId, index),
+			Name:          bot.Name,
+			AgentId:       bot.AgentId,
+			Team:          player.Team,
+			PlayerIndices: []uint32{index},
+			RootDir:       bot.RootDir,
+			RunCommand:    bot.RunCommand,
+		}
+		if bot.Hivemind {
+			hiveminds[hivemindKey] = agent
+		}
+		agents = append(agents, agent)
+	}
+
+	for i, script := range match.ScriptConfigurations {
+		if script.RunCommand == "" {
+			continue
+		}
+
+		agents = append(agents, &AgentProcess{
+			Key:           fmt.Sprintf("script-%s-%d", script.AgentId, i),
+			Name:          script.Name,
+			AgentId:       script.AgentId,
+			PlayerIndices: []uint32{},
+			IsScript:      true,
+			RootDir:       script.RootDir,
+			RunCommand:    script.RunCommand,
+		})
+	}
+
+	return agents
+}
+
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+
+	return exec.Command("sh", "-c", command)
+}
+
+func (m *AgentManager) agentEnv(agentId string) []string {
+	env := append(os.Environ(), "RLBOT_AGENT_ID="+agentId)
+
+	m.mu.Lock()
+	rlbotAddress := m.rlbotAddress
+	m.mu.Unlock()
+
+	ip, port, err := net.SplitHostPort(rlbotAddress)
+	if err == nil {
+		env = append(env, "RLBOT_SERVER_IP="+ip, "RLBOT_SERVER_PORT="+port)
+	}
+
+	return env
+}
+
+func (m *AgentManager) pipeOutput(agent *AgentProcess, log *RotatingLog, stream string, reader io.Reader, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := AgentLogLine{
+			Agent:  agent.Key,
+			Stream: stream,
+			Line:   scanner.Text(),
+			Time:   time.Now(),
+		}
+
+		fmt.Fprintf(log, "%s [%s] %s\n", line.Time.Format(time.RFC3339), stream, line.Line)
+		m.logLines.add(line)
+	}
+}
+
+func logGui(log *RotatingLog, format string, args ...any) {
+	fmt.Fprintf(log, "%s [gui] %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+func (m *AgentManager) logPath(key string) string {
+	return filepath.Join(m.logDir, sanitizeFileName(key)+".log")
+}
+
+// start launches the process of agent and captures its output
+func (m *AgentManager) start(agent *AgentProcess) error {
+	cmd := shellCommand(agent.RunCommand)
+	cmd.Dir = agent.RootDir
+	cmd.Env = m.agentEnv(agent.AgentId)
+	prepareProcess(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	logPath := m.logPath(agent.Key)
+	log, err := OpenRotatingLog(logPath, defaultLogSize, defaultLogFiles)
+	if err != nil {
+		return err
+	}
+
+	logGui(log, "starting %q in %s", agent.RunCommand, agent.RootDir)
+
+	// before starting, so that the processes the agent starts are limited too
+	settings := m.Settings()
+	cleanupLimits, err := limitProcess(cmd, agent.Key, settings.Limits)
+	if err != nil {
+		logGui(log, "couldn't apply resource limits: %s", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		logGui(log, "failed to start: %s", err)
+		cleanupLimits()
+		log.Close()
+		return err
+	}
+
+	done := make(chan struct{})
+
+	m.mu.Lock()
+	if agent.stopped == nil {
+		agent.stopped = make(chan struct{})
+	}
+	agent.cmd = cmd
+	agent.log = log
+	agent.LogPath = logPath
+	agent.done = done
+	agent.Pid = cmd.Process.Pid
+	agent.Running = true
+	agent.ExitCode = 0
+	agent.Crashed = false
+	status := *agent
+	m.mu.Unlock()
+
+	emitEvent("agent-start", status)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go m.pipeOutput(agent, log, "stdout", stdout, &wg)
+	go m.pipeOutput(agent, log, "stderr", stderr, &wg)
+
+	go func() {
+		// all output has to be read before calling Wait
+		wg.Wait()
+		err := cmd.Wait()
+		cleanupLimits()
+
+		m.mu.Lock()
+		agent.Running = false
+		agent.ExitCode = cmd.ProcessState.ExitCode()
+		// a clean exit is expected once the match ends
+		agent.Crashed = !agent.stopping && agent.ExitCode != 0
+		status := *agent
+		m.mu.Unlock()
+
+		if err != nil {
+			logGui(log, "exited: %s", err)
+		} else {
+			logGui(log, "exited")
+		}
+
+		emitEvent("agent-exit", status)
+
+		if status.Crashed {
+			m.handleCrash(agent, log)
+		}
+		log.Close()
+		close(done)
+	}()
+
+	return nil
+}
+
+// handleCrash restarts a crashed agent if the supervisor settings allow it
+func (m *AgentManager) handleCrash(agent *AgentProcess, log *RotatingLog) {
+	settings := m.Settings()
+
+	m.mu.Lock()
+	restarts := agent.Restarts
+	stopped := agent.stopped
+	m.mu.Unlock()
+
+	if !settings.RestartOnCrash || restarts >= settings.MaxRestarts {
+		logGui(log, "not restarting after %d restarts", restarts)
+		return
+	}
+
+	delay := settings.backoff(restarts)
+	logGui(log, "restarting in %s", delay)
+	select {
+	case <-time.After(delay):
+	case <-stopped:
+		logGui(log, "not restarting, stopped while waiting")
+		return
+	}
+
+	m.mu.Lock()
+	if agent.stopping {
+		m.mu.Unlock()
+		return
+	}
+	agent.Restarts++
+	m.mu.Unlock()
+
+	// start opens the log again, it can't be shared between runs
+	log.Close()
+	if err := m.start(agent); err != nil {
+		println("WARN: failed to restart " + agent.Name + ": " + err.Error())
+	}
+}
+
+// stop kills the process tree of agent without treating it as a crash
+func (m *AgentManager) stop(agent *AgentProcess) {
+	m.mu.Lock()
+	if !agent.stopping && agent.stopped != nil {
+		close(agent.stopped)
+	}
+	agent.stopping = true
+	pid := agent.Pid
+	running := agent.Running
+	m.mu.Unlock()
+
+	if running {
+		if err := killProcessTree(pid); err != nil {
+			println("WARN: failed to kill " + agent.Name + ": " + err.Error())
+		}
+	}
+}
+
+// StartMatchAgents stops the agents of the previous match and launches those of match
+func (m *AgentManager) StartMatchAgents(match *flat.MatchConfigurationT) error {
+	m.StopAll()
+
+	agents := agentsFromMatch(match)
+
+	m.mu.Lock()
+	m.processes = agents
+	m.mu.Unlock()
+
+	var errs []error
+	for _, agent := range agents {
+		if err := m.start(agent); err != nil {
+			errs = append(errs, fmt.Errorf("failed to start %s: %w", agent.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *AgentManager) StopAll() {
+	m.mu.Lock()
+	processes := m.processes
+	for key, cancel := range m.watchers {
+		cancel()
+		delete(m.watchers, key)
+	}
+	for _, agent := range processes {
+		agent.AutoReload = false
+	}
+	m.mu.Unlock()
+
+	for _, agent := range processes {
+		m.stop(agent)
+	}
+
+	// the agents of a stopped match can't be reloaded or looked up by player index
+	m.mu.Lock()
+	m.processes = nil
+	m.mu.Unlock()
+}
+
+func (m *AgentManager) Find(key string) (AgentProcess, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, agent := range m.processes {
+		if agent.Key == key {
+			return *agent, true
+		}
+	}
+
+	return AgentProcess{}, false
+}
+
+func (m *AgentManager) List() []AgentProcess {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	agents := make([]AgentProcess, len(m.processes))
+	for i, agent := range m.processes {
+		agents[i] = *agent
+	}
+
+	return agents
+}
+
+func (a *App) GetAgents() []AgentProcess {
+	return a.agents.List()
+}
+
+// GetAgentLog returns the last maxLines lines of an agent's log file
+func (a *App) GetAgentLog(key string, maxLines int) ([]string, error) {
+	agent, ok := a.agents.Find(key)
+	if !ok {
+		// the agents of a stopped match are forgotten, but their logs stay
+		logPath := a.agents.logPath(key)
+		if _, err := os.Stat(logPath); err != nil {
+			return nil, errors.New("unknown agent: " + key)
+		}
+		return tailLines(logPath, maxLines)
+	}
+
+	return tailLines(agent.LogPath, maxLines)
+}
+
+func (a *App) StopAgents() {
+	a.agents.StopAll()
+}
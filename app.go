@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	rlbot "github.com/RLBot/go-interface"
@@ -22,7 +23,14 @@ type RawReleaseInfo struct {
 // App struct
 type App struct {
 	latestReleaseJson []RawReleaseInfo
-	rlbotAddress      string
+	settings          *SettingsStore
+	agents            *AgentManager
+	library           *LoadoutLibrary
+	showcases         *ShowcaseManager
+	server            *ServerManager
+	// replaced when the RocketHost settings change, use rhostClient
+	rhost     atomic.Pointer[RHostClient]
+	rhostBots *rhostBotCache
 }
 
 func (a *App) IgnoreMe(
@@ -89,23 +97,36 @@ func (a *App) RepairBotpack(repo string, installPath string) (string, error) {
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	ip := os.Getenv("RLBOT_SERVER_IP")
-	if ip == "" {
-		ip = "127.0.0.1"
+	var latest_release_json []RawReleaseInfo
+	app := &App{
+		latestReleaseJson: latest_release_json,
 	}
+	app.settings = LoadSettingsStore(app.settingsPath())
+	app.agents = NewAgentManager(app.rlbotAddress(), filepath.Join(app.GetDefaultPath(), "logs", "agents"))
+	app.library = NewLoadoutLibrary(filepath.Join(app.GetDefaultPath(), "loadouts"))
+	app.showcases = NewShowcaseManager()
+	app.server = NewServerManager(
+		filepath.Join(app.GetDefaultPath(), "rlbotserver"),
+		filepath.Join(app.GetDefaultPath(), "logs", "rlbotserver.log"),
+	)
+	app.applySettings(app.settings.Get())
+	app.rhostBots = newRHostBotCache(filepath.Join(app.GetDefaultPath(), "rockethost", "bots.json"))
+	loadUserItems(app.itemsPath())
 
-	port := os.Getenv("RLBOT_SERVER_PORT")
-	if port == "" {
-		port = "23234"
-	}
+	return app
+}
 
-	rlbot_address := ip + ":" + port
+// ServiceShutdown is called by wails when the GUI is closed
+func (a *App) ServiceShutdown() error {
+	a.agents.StopAll()
+	a.showcases.Stop()
 
-	var latest_release_json []RawReleaseInfo
-	return &App{
-		latest_release_json,
-		rlbot_address,
+	if a.settings.Get().Server.StopWithGui {
+		if err := a.server.Stop(); err != nil {
+			println("WARN: couldn't stop RLBotServer: " + err.Error())
+		}
 	}
+	return nil
 }
 
 func recursiveTomlSearch(root, tomlType string) ([]string, error) {
@@ -134,15 +155,22 @@ type Result struct {
 }
 
 type ExtraOptions struct {
-	Freeplay              bool                `json:"freeplay"`
-	EnableRendering       flat.DebugRendering `json:"enableRendering"`
-	EnableStateSetting    bool                `json:"enableStateSetting"`
-	InstantStart          bool                `json:"instantStart"`
-	SkipReplays           bool                `json:"skipReplays"`
-	AutoSaveReplay        bool                `json:"autoSaveReplay"`
-	ExistingMatchBehavior byte                `json:"existingMatchBehavior"`
-	AutoStartAgents       bool                `json:"autoStartAgents"`
-	WaitForAgents         bool                `json:"waitForAgents"`
+	Freeplay              bool                `toml:"freeplay" json:"freeplay"`
+	EnableRendering       flat.DebugRendering `toml:"enable_rendering" json:"enableRendering"`
+	EnableStateSetting    bool                `toml:"enable_state_setting" json:"enableStateSetting"`
+	InstantStart          bool                `toml:"instant_start" json:"instantStart"`
+	SkipReplays           bool                `toml:"skip_replays" json:"skipReplays"`
+	AutoSaveReplay        bool                `toml:"auto_save_replay" json:"autoSaveReplay"`
+	ExistingMatchBehavior byte                `toml:"existing_match_behavior" json:"existingMatchBehavior"`
+	AutoStartAgents       bool                `toml:"auto_start_agents" json:"autoStartAgents"`
+	WaitForAgents         bool                `toml:"wait_for_agents" json:"waitForAgents"`
+	// Launch agents from the GUI instead of RLBotServer, capturing their output
+	ManageAgents bool `toml:"manage_agents" json:"manageAgents"`
+	// Give bots without a loadout a random one
+	RandomLoadouts     bool   `toml:"random_loadouts" json:"randomLoadouts"`
+	RandomLoadoutTheme string `toml:"random_loadout_theme" json:"randomLoadoutTheme"`
+	// Only give them items that can come certified
+	RandomLoadoutCertified bool `toml:"random_loadout_certified" json:"randomLoadoutCertified"`
 }
 
 type StartMatchOptions struct {
@@ -281,7 +309,9 @@ func WaitForMatchReady(
 	return nil
 }
 
-func StartAndWaitForMatch(rlbotAddress string, match *flat.MatchConfigurationT) error {
+// StartAndWaitForMatch sends match to RLBotServer and waits for it to start.
+// If not nil, onMatchSent is called right after the match configuration was sent.
+func StartAndWaitForMatch(rlbotAddress string, match *flat.MatchConfigurationT, onMatchSent func()) error {
 	conn, err := rlbot.Connect(rlbotAddress)
 	if err != nil {
 		return fmt.Errorf("Failed to reconnect to RLBotServer at %s", rlbotAddress)
@@ -299,6 +329,10 @@ func StartAndWaitForMatch(rlbotAddress string, match *flat.MatchConfigurationT)
 
 	conn.SendPacket(match)
 
+	if onMatchSent != nil {
+		onMatchSent()
+	}
+
 	// Wait for the match to start, with timeouts
 	err = WaitForMatchReady(
 		&conn,
@@ -314,63 +348,44 @@ func StartAndWaitForMatch(rlbotAddress string, match *flat.MatchConfigurationT)
 	return nil
 }
 
+var gameModes = map[string]flat.GameMode{
+	"Soccar":     flat.GameModeSoccar,
+	"Hoops":      flat.GameModeHoops,
+	"Dropshot":   flat.GameModeDropshot,
+	"Snowday":    flat.GameModeSnowday,
+	"Rumble":     flat.GameModeRumble,
+	"Heatseeker": flat.GameModeHeatseeker,
+	"Gridiron":   flat.GameModeGridiron,
+	"Knockout":   flat.GameModeKnockout,
+}
+
 func (a *App) StartMatch(options StartMatchOptions) Result {
-	var gameMode flat.GameMode
-	switch options.GameMode {
-	case "Soccar":
-		gameMode = flat.GameModeSoccar
-	case "Hoops":
-		gameMode = flat.GameModeHoops
-	case "Dropshot":
-		gameMode = flat.GameModeDropshot
-	case "Snowday":
-		gameMode = flat.GameModeSnowday
-	case "Rumble":
-		gameMode = flat.GameModeRumble
-	case "Heatseeker":
-		gameMode = flat.GameModeHeatseeker
-	case "Gridiron":
-		gameMode = flat.GameModeGridiron
-	case "Knockout":
-		gameMode = flat.GameModeKnockout
-	default:
+	gameMode, ok := gameModes[options.GameMode]
+	if !ok {
 		println("No mode chosen, defaulting to soccar")
 		gameMode = flat.GameModeSoccar
 	}
 
-	var launcher flat.Launcher
-	switch options.Launcher {
-	case "steam":
-		launcher = flat.LauncherSteam
-	case "epic":
-		launcher = flat.LauncherEpic
-	case "legendary", "heroic":
-		launcher = flat.LauncherCustom
-		options.LauncherArg = options.Launcher
-	case "custom":
-		launcher = flat.LauncherCustom
-	case "nolaunch":
-		launcher = flat.LauncherNoLaunch
-	default:
-		println("No launcher chosen, defaulting to NoLaunch")
-		launcher = flat.LauncherNoLaunch
+	launcher, launcherArg, err := resolveLauncherOrNoLaunch(options.Launcher, options.LauncherArg)
+	if err != nil {
+		return Result{false, err.Error()}
 	}
 
 	playerConfigs :=
 		make([]*flat.PlayerConfigurationT, len(options.BluePlayers)+len(options.OrangePlayers))
 
 	for i, playerInfo := range options.BluePlayers {
-		playerConfigs[i] = playerInfo.ToPlayer().ToPlayerConfig(0)
+		playerConfigs[i] = a.toPlayer(playerInfo).ToPlayerConfig(0)
 	}
 
 	for i, playerInfo := range options.OrangePlayers {
-		playerConfigs[i+len(options.BluePlayers)] = playerInfo.ToPlayer().ToPlayerConfig(1)
+		playerConfigs[i+len(options.BluePlayers)] = a.toPlayer(playerInfo).ToPlayerConfig(1)
 	}
 
 	scriptConfigs :=
 		make([]*flat.ScriptConfigurationT, len(options.Scripts))
 	for i, info := range options.Scripts {
-		scriptConfigs[i] = info.ToScriptConfig()
+		scriptConfigs[i] = a.withCurrentRunner(info).ToScriptConfig()
 	}
 
 	match := flat.MatchConfigurationT{
@@ -388,11 +403,38 @@ func (a *App) StartMatch(options StartMatchOptions) Result {
 		SkipReplays:           options.ExtraOptions.SkipReplays,
 		AutoSaveReplay:        options.ExtraOptions.AutoSaveReplay,
 		Launcher:              launcher,
-		LauncherArg:           options.LauncherArg,
+		LauncherArg:           launcherArg,
 		ExistingMatchBehavior: flat.ExistingMatchBehavior(options.ExtraOptions.ExistingMatchBehavior),
 	}
 
-	err := StartAndWaitForMatch(a.rlbotAddress, &match)
+	if options.ExtraOptions.RandomLoadouts {
+		constraints := LoadoutConstraints{
+			Theme:         options.ExtraOptions.RandomLoadoutTheme,
+			OnlyCertified: options.ExtraOptions.RandomLoadoutCertified,
+		}
+		if err := randomizeMissingLoadouts(&match, constraints); err != nil {
+			return Result{false, err.Error()}
+		}
+	}
+
+	var onMatchSent func()
+	if options.ExtraOptions.ManageAgents {
+		match.AutoStartAgents = false
+		onMatchSent = func() {
+			if err := a.agents.StartMatchAgents(&match); err != nil {
+				println("WARN: " + err.Error())
+			}
+		}
+	} else {
+		// RLBotServer starts the agents, the ones the GUI ran for the previous match have to go
+		a.agents.StopAll()
+	}
+
+	if err := a.ensureServer(); err != nil {
+		return Result{false, err.Error()}
+	}
+
+	err = StartAndWaitForMatch(a.rlbotAddress(), &match, onMatchSent)
 	if err != nil {
 		return Result{false, err.Error()}
 	}
@@ -401,7 +443,19 @@ func (a *App) StartMatch(options StartMatchOptions) Result {
 }
 
 func (a *App) StopMatch(shutdownServer bool) Result {
-	conn, err := rlbot.Connect(a.rlbotAddress)
+	a.agents.StopAll()
+	a.showcases.Stop()
+
+	// wait for the RLBotServer run by the GUI to exit, or kill it,
+	// unless the match is on the server of another profile
+	if shutdownServer && a.server.RunningAt(a.rlbotAddress()) {
+		if err := a.server.Stop(); err != nil {
+			return Result{false, "Failed to stop RLBotServer: " + err.Error()}
+		}
+		return Result{true, ""}
+	}
+
+	conn, err := rlbot.Connect(a.rlbotAddress())
 	if err != nil {
 		return Result{false, "Failed to connect to rlbot"}
 	}
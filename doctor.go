@@ -0,0 +1,441 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	RuntimePython = "python"
+	RuntimeNode   = "node"
+	RuntimeDotnet = "dotnet"
+	RuntimeJava   = "java"
+)
+
+type RuntimeCheck struct {
+	Runtime string `json:"runtime"`
+	// Why we think the bot needs this runtime
+	Reason string `json:"reason"`
+	// Version constraint declared by the bot, if any (e.g. ">=3.11")
+	Requirement string `json:"requirement"`
+	Installed   bool   `json:"installed"`
+	Path        string `json:"path"`
+	Version     string `json:"version"`
+	// False if the installed version doesn't meet Requirement
+	Satisfied bool `json:"satisfied"`
+}
+
+type BotDiagnostics struct {
+	TomlPath string         `json:"tomlPath"`
+	Runtimes []RuntimeCheck `json:"runtimes"`
+	// Path to the python venv the bot expects, if any
+	Venv       string `json:"venv"`
+	VenvExists bool   `json:"venvExists"`
+	// If SetupBotEnvironment knows how to install this bot's dependencies
+	CanSetup bool     `json:"canSetup"`
+	Problems []string `json:"problems"`
+}
+
+var versionRegex = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// runtimeExecutables are the names we look for in PATH, in order of preference
+func runtimeExecutables(name string) []string {
+	switch name {
+	case RuntimePython:
+		if runtime.GOOS == "windows" {
+			return []string{"python", "py"}
+		}
+		return []string{"python3", "python"}
+	default:
+		return []string{name}
+	}
+}
+
+func runtimeVersion(name, path string) string {
+	arg := "--version"
+	if name == RuntimeJava {
+		arg = "-version"
+	}
+
+	// java prints its version to stderr
+	output, err := exec.Command(path, arg).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+
+	return versionRegex.FindString(string(output))
+}
+
+func parseVersion(version string) []int {
+	match := versionRegex.FindStringSubmatch(version)
+	if match == nil {
+		return nil
+	}
+
+	parts := []int{}
+	for _, part := range match[1:] {
+		if part == "" {
+			part = "0"
+		}
+		num, _ := strconv.Atoi(part)
+		parts = append(parts, num)
+	}
+
+	return parts
+}
+
+// versionParts counts the parts of version that are written out, 2 for "3.9"
+func versionParts(version string) int {
+	parts := 0
+	match := versionRegex.FindStringSubmatch(version)
+	for _, part := range match[1:] {
+		if part != "" {
+			parts++
+		}
+	}
+
+	return parts
+}
+
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+
+	return 0
+}
+
+// versionSatisfies checks simple comma separated constraints like ">=3.9,<3.13"
+func versionSatisfies(version string, requirement string) bool {
+	have := parseVersion(version)
+	if have == nil || requirement == "" {
+		return true
+	}
+
+	for _, constraint := range strings.Split(requirement, ",") {
+		constraint = strings.TrimSpace(constraint)
+		wanted := strings.TrimLeft(constraint, "<>=!~ ")
+		op := strings.TrimSpace(strings.TrimSuffix(constraint, wanted))
+
+		want := parseVersion(wanted)
+		if want == nil {
+			continue
+		}
+
+		cmp := compareVersions(have, want)
+		switch op {
+		case ">=":
+			if cmp < 0 {
+				return false
+			}
+		case "~=":
+			// ~=3.9 means >=3.9,==3.*, ~=3.9.2 means >=3.9.2,==3.9.*
+			fixed := versionParts(wanted) - 1
+			if cmp < 0 || compareVersions(have[:fixed], want[:fixed]) != 0 {
+				return false
+			}
+		case ">":
+			if cmp <= 0 {
+				return false
+			}
+		case "<=":
+			if cmp > 0 {
+				return false
+			}
+		case "<":
+			if cmp >= 0 {
+				return false
+			}
+		case "==":
+			if cmp != 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func globExists(pattern string) bool {
+	matches, err := filepath.Glob(pattern)
+	return err == nil && len(matches) > 0
+}
+
+// detectRuntimes guesses which runtimes a bot needs from its run command and the files in its root dir
+func detectRuntimes(rootDir, runCommand string) []RuntimeCheck {
+	checks := []RuntimeCheck{}
+	seen := map[string]bool{}
+	add := func(name, reason string) {
+		if !seen[name] {
+			seen[name] = true
+			checks = append(checks, RuntimeCheck{Runtime: name, Reason: reason})
+		}
+	}
+
+	command := strings.ToLower(runCommand)
+	for _, token := range strings.Fields(command) {
+		base := filepath.Base(strings.ReplaceAll(token, "\\", "/"))
+		switch {
+		case strings.HasPrefix(base, "python"), base == "py", base == "py.exe", strings.HasSuffix(base, ".py"):
+			add(RuntimePython, "run command uses "+base)
+		case base == "node", base == "node.exe", base == "npm", base == "npx", strings.HasSuffix(base, ".js"):
+			add(RuntimeNode, "run command uses "+base)
+		case base == "dotnet", base == "dotnet.exe", strings.HasSuffix(base, ".dll"):
+			add(RuntimeDotnet, "run command uses "+base)
+		case base == "java", base == "java.exe", base == "javaw.exe", strings.HasSuffix(base, ".jar"):
+			add(RuntimeJava, "run command uses "+base)
+		}
+	}
+
+	if fileExists(filepath.Join(rootDir, "requirements.txt")) {
+		add(RuntimePython, "requirements.txt found")
+	}
+	if fileExists(filepath.Join(rootDir, "pyproject.toml")) {
+		add(RuntimePython, "pyproject.toml found")
+	}
+	if fileExists(filepath.Join(rootDir, "package.json")) {
+		add(RuntimeNode, "package.json found")
+	}
+	if globExists(filepath.Join(rootDir, "*.csproj")) {
+		add(RuntimeDotnet, ".csproj found")
+	}
+	if fileExists(filepath.Join(rootDir, "pom.xml")) || fileExists(filepath.Join(rootDir, "build.gradle")) {
+		add(RuntimeJava, "java build file found")
+	}
+
+	return checks
+}
+
+// pythonRequirement reads requires-python from pyproject.toml, if any
+func pythonRequirement(rootDir string) string {
+	var pyproject struct {
+		Project struct {
+			RequiresPython string `toml:"requires-python"`
+		} `toml:"project"`
+	}
+
+	data, err := os.ReadFile(filepath.Join(rootDir, "pyproject.toml"))
+	if err != nil {
+		return ""
+	}
+
+	if _, err := toml.Decode(string(data), &pyproject); err != nil {
+		return ""
+	}
+
+	return pyproject.Project.RequiresPython
+}
+
+var windowsDriveRegex = regexp.MustCompile(`^[a-zA-Z]:/`)
+
+// isWithin reports whether path is dir or inside of it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// venvFromRunCommand finds the venv a run command like "./venv/bin/python bot.py" expects.
+// Only folders inside rootDir or with a pyvenv.cfg count, so /usr/bin/python isn't a venv.
+func venvFromRunCommand(rootDir, runCommand string) string {
+	for _, token := range strings.Fields(runCommand) {
+		token = strings.Trim(strings.ReplaceAll(token, "\\", "/"), `"'`)
+		parts := strings.Split(token, "/")
+		for i, part := range parts {
+			if (part != "bin" && part != "Scripts") || i == 0 {
+				continue
+			}
+
+			venv := filepath.FromSlash(strings.Join(parts[:i], "/"))
+			if !strings.HasPrefix(token, "/") && !windowsDriveRegex.MatchString(token) {
+				venv = filepath.Join(rootDir, venv)
+			}
+			venv = filepath.Clean(venv)
+
+			if fileExists(filepath.Join(venv, "pyvenv.cfg")) || isWithin(rootDir, venv) {
+				return venv
+			}
+		}
+	}
+
+	return ""
+}
+
+func venvPython(venv string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(venv, "Scripts", "python.exe")
+	}
+
+	return filepath.Join(venv, "bin", "python")
+}
+
+func findRuntime(name string) (string, string) {
+	for _, executable := range runtimeExecutables(name) {
+		path, err := exec.LookPath(executable)
+		if err == nil {
+			return path, runtimeVersion(name, path)
+		}
+	}
+
+	return "", ""
+}
+
+func diagnoseBot(info BotInfo) BotDiagnostics {
+	rootDir := info.Config.Settings.RootDir
+	runCommand := info.RunCommand()
+	if info.Runner != nil && info.Runner.Kind != RunnerNative {
+		// look at the unwrapped command, it's still useful for detecting the runtime
+		runCommand = info.Config.Settings.RunCommand
+	}
+
+	diagnostics := BotDiagnostics{
+		TomlPath: info.TomlPath,
+		Runtimes: detectRuntimes(rootDir, runCommand),
+		Problems: []string{},
+	}
+
+	if info.RunCommand() == "" {
+		diagnostics.Problems = append(diagnostics.Problems, "bot has no run command for this platform")
+	}
+
+	for i := range diagnostics.Runtimes {
+		check := &diagnostics.Runtimes[i]
+		if check.Runtime == RuntimePython {
+			check.Requirement = pythonRequirement(rootDir)
+			diagnostics.Venv = venvFromRunCommand(rootDir, runCommand)
+		}
+
+		// the bot runs with the python of its venv, the one on PATH only creates the venv
+		if venv := diagnostics.Venv; check.Runtime == RuntimePython && venv != "" && fileExists(venvPython(venv)) {
+			check.Path = venvPython(venv)
+			check.Version = runtimeVersion(check.Runtime, check.Path)
+		} else {
+			check.Path, check.Version = findRuntime(check.Runtime)
+		}
+		check.Installed = check.Path != ""
+		check.Satisfied = check.Installed && versionSatisfies(check.Version, check.Requirement)
+
+		if !check.Installed {
+			diagnostics.Problems = append(diagnostics.Problems, check.Runtime+" is not installed")
+		} else if !check.Satisfied {
+			diagnostics.Problems = append(diagnostics.Problems,
+				check.Runtime+" "+check.Version+" doesn't satisfy "+check.Requirement)
+		}
+
+		switch check.Runtime {
+		case RuntimePython:
+			if diagnostics.Venv != "" {
+				diagnostics.VenvExists = fileExists(venvPython(diagnostics.Venv))
+				if !diagnostics.VenvExists {
+					diagnostics.Problems = append(diagnostics.Problems, "venv not found at "+diagnostics.Venv)
+				}
+			}
+
+			hasDeps := fileExists(filepath.Join(rootDir, "requirements.txt")) ||
+				fileExists(filepath.Join(rootDir, "pyproject.toml"))
+			diagnostics.CanSetup = diagnostics.CanSetup || (check.Satisfied && hasDeps)
+		case RuntimeNode:
+			diagnostics.CanSetup = diagnostics.CanSetup ||
+				(check.Installed && fileExists(filepath.Join(rootDir, "package.json")))
+		}
+	}
+
+	return diagnostics
+}
+
+// DiagnoseBots checks the runtimes of the given bots, keyed by toml path
+func (a *App) DiagnoseBots(infos []BotInfo) map[string]BotDiagnostics {
+	results := make(map[string]BotDiagnostics, len(infos))
+	for _, info := range infos {
+		results[info.TomlPath] = diagnoseBot(a.withCurrentRunner(info))
+	}
+
+	return results
+}
+
+func (a *App) DiagnoseBot(info BotInfo) BotDiagnostics {
+	return diagnoseBot(a.withCurrentRunner(info))
+}
+
+// SetupBotEnvironment installs the dependencies of a bot inside its folder,
+// returning the output of the commands that were run
+func (a *App) SetupBotEnvironment(info BotInfo) (string, error) {
+	diagnostics := diagnoseBot(a.withCurrentRunner(info))
+	if !diagnostics.CanSetup {
+		return "", errors.New("don't know how to set up the environment of this bot")
+	}
+
+	rootDir := info.Config.Settings.RootDir
+	var log strings.Builder
+	run := func(name string, args ...string) error {
+		log.WriteString("> " + name + " " + strings.Join(args, " ") + "\n")
+		cmd := exec.Command(name, args...)
+		cmd.Dir = rootDir
+		output, err := cmd.CombinedOutput()
+		log.Write(output)
+		return err
+	}
+
+	for _, check := range diagnostics.Runtimes {
+		switch check.Runtime {
+		case RuntimePython:
+			venv := diagnostics.Venv
+			if venv == "" {
+				venv = filepath.Join(rootDir, "venv")
+			}
+
+			if !fileExists(venvPython(venv)) {
+				if !isWithin(rootDir, venv) {
+					return log.String(), errors.New("won't create a venv outside of the bot's folder at " + venv)
+				}
+				if err := run(check.Path, "-m", "venv", venv); err != nil {
+					return log.String(), err
+				}
+			}
+
+			python := venvPython(venv)
+			if err := run(python, "-m", "pip", "install", "--upgrade", "pip"); err != nil {
+				return log.String(), err
+			}
+
+			var err error
+			if fileExists(filepath.Join(rootDir, "requirements.txt")) {
+				err = run(python, "-m", "pip", "install", "-r", "requirements.txt")
+			} else {
+				err = run(python, "-m", "pip", "install", ".")
+			}
+			if err != nil {
+				return log.String(), err
+			}
+		case RuntimeNode:
+			if !fileExists(filepath.Join(rootDir, "package.json")) {
+				continue
+			}
+
+			npm, err := exec.LookPath("npm")
+			if err != nil {
+				return log.String(), errors.New("npm is not installed")
+			}
+
+			if err := run(npm, "install"); err != nil {
+				return log.String(), err
+			}
+		}
+	}
+
+	return log.String(), nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import "github.com/wailsapp/wails/v3/pkg/application"
+
+// emitEvent sends an event to the frontend, if the GUI is running
+func emitEvent(name string, data any) {
+	app := application.Get()
+	if app == nil {
+		return
+	}
+
+	// Emit would wrap data in an array
+	app.Event.EmitEvent(&application.CustomEvent{
+		Name: name,
+		Data: data,
+	})
+}
@@ -1,5 +1,4 @@
-//@ts-ignore
-import ItemsCsv from "../../assets/items.csv";
+import { App } from "../../../bindings/gui";
 import { ITEM_TYPES } from "./itemtypes";
 
 export interface CsvItem {
@@ -37,8 +36,8 @@ function renameItem(item: CsvItem, category: string) {
 const EXCLUDE_ITEMS = [5364, 5365, 5366, 5367, 5368, 5369];
 
 export async function getAndParseItems() {
-  const resp = await fetch(ItemsCsv);
-  const csv = await resp.text();
+  // comes from the backend so updates to the item database show up without a rebuild
+  const csv = await App.GetItemsCsv();
   const lines = csv.split(/\r?\n/);
 
   const items: {
@@ -59,7 +58,7 @@ export async function getAndParseItems() {
       items[category].push({
         id,
         uuid: columns[2],
-        name: columns[3],
+        name: columns.slice(3).join(","),
       });
   }
 
@@ -8,12 +8,16 @@ import {
 } from "../bindings/gui";
 import App from "./App.svelte";
 import SuperJSON from "superjson";
+import { loadSettings } from "./settings";
 
-const app: any = mount(App, {
-  target: document.body,
-  // props: {
-  //   name: "world",
-  // },
+// components read their settings from the settings store when they're created
+loadSettings().then(() => {
+  mount(App, {
+    target: document.body,
+    // props: {
+    //   name: "world",
+    // },
+  });
 });
 
 SuperJSON.registerClass(BotInfo);
@@ -1,9 +1,123 @@
-import { writable } from "svelte/store";
+import { get, writable } from "svelte/store";
+import { App, Settings } from "../bindings/gui";
 import { MAPS_STANDARD } from "./arena-names";
 
-export const mapStore = writable(
-  localStorage.getItem("MS_MAP") || MAPS_STANDARD["DFH Stadium"],
-);
+// set once the settings file was read, until then there's nothing to save
+let loaded = false;
+
+// The settings of the GUI, loaded from the settings file before the app is mounted.
+// Components read them with getSettings and change them with updateSettings,
+// which saves them to the file.
+export const settingsStore = writable<Settings>(new Settings());
+
+export function getSettings(): Settings {
+  return get(settingsStore);
+}
+
+export function updateSettings(update: (settings: Settings) => void) {
+  settingsStore.update((settings) => {
+    update(settings);
+    return settings;
+  });
+  scheduleSave();
+}
+
+export const mapStore = writable(MAPS_STANDARD["DFH Stadium"]);
 mapStore.subscribe((value) => {
-  localStorage.setItem("MS_MAP", value);
+  if (loaded && value !== getSettings().match.map) {
+    updateSettings((settings) => {
+      settings.match.map = value;
+    });
+  }
 });
+
+// set once the localStorage of older GUIs has been moved to the settings file
+const MOVED_KEY = "SETTINGS_IN_FILE";
+
+function readJSON(key: string, fallback: any): any {
+  try {
+    return JSON.parse(localStorage.getItem(key) ?? "") ?? fallback;
+  } catch {
+    return fallback;
+  }
+}
+
+function readString(key: string, fallback: string): string {
+  return localStorage.getItem(key) ?? fallback;
+}
+
+// fromLocalStorage moves the settings that older GUIs kept in localStorage into settings
+function fromLocalStorage(settings: Settings) {
+  settings.botPaths = readJSON("BOT_SEARCH_PATHS", []).map((path: any) => ({
+    installPath: path.installPath,
+    visible: path.visible,
+    repo: path.repo ?? "",
+    tagName: path.tagName ?? "",
+  }));
+  settings.favorites = readJSON("FAVORITES", []);
+
+  const match = settings.match;
+  match.map = readString("MS_MAP", match.map);
+  match.gameMode = readString("MS_MODE", match.gameMode);
+  match.randomizeMap = localStorage.getItem("MS_RANDOMIZE_MAP") === "true";
+  match.launcher = readString("MS_LAUNCHER", match.launcher);
+  match.launcherArg = readString("MS_LAUNCHER_ARG", match.launcherArg);
+  match.mutators = readJSON("MS_MUTATORS", {});
+  match.extraOptions = { ...match.extraOptions, ...readJSON("MS_EXTRAOPTIONS", {}) };
+
+  settings.rhostMatch.server = readString("RHOST_SERVER_ADDR", settings.rhostMatch.server);
+
+  const editor = settings.loadoutEditor;
+  editor.showcaseType = readString("LOADOUT_SHOWCASE_TYPE", editor.showcaseType);
+  editor.previewOnChange = localStorage.getItem("LOADOUT_PREVIEW_ON_CHANGE") === "true";
+}
+
+let saveTimeout: ReturnType<typeof setTimeout> | null = null;
+
+// saveSettings writes the settings the frontend changes to the settings file
+export async function saveSettings() {
+  if (saveTimeout) {
+    clearTimeout(saveTimeout);
+    saveTimeout = null;
+  }
+  if (!loaded) return;
+
+  // start from the file, other settings like wine are changed through their own App methods
+  const current = getSettings();
+  const settings = await App.GetSettings();
+  settings.botPaths = current.botPaths;
+  settings.favorites = current.favorites;
+  settings.match = current.match;
+  settings.rhostMatch = current.rhostMatch;
+  settings.loadoutEditor = current.loadoutEditor;
+  await App.SetSettings(settings);
+}
+
+function scheduleSave() {
+  if (saveTimeout) clearTimeout(saveTimeout);
+  saveTimeout = setTimeout(() => {
+    saveSettings().catch((err) => console.error("Couldn't save settings:", err));
+  }, 500);
+}
+
+// applySettings replaces the settings of the frontend, without saving them
+export function applySettings(settings: Settings) {
+  settingsStore.set(settings);
+  if (settings.match.map) mapStore.set(settings.match.map);
+}
+
+// loadSettings reads the settings file, it has to run before the app is mounted
+export async function loadSettings() {
+  try {
+    const settings = await App.GetSettings();
+    if (localStorage.getItem(MOVED_KEY) !== "true") {
+      fromLocalStorage(settings);
+      await App.SetSettings(settings);
+      localStorage.setItem(MOVED_KEY, "true");
+    }
+    applySettings(settings);
+    loaded = true;
+  } catch (err) {
+    console.error("Couldn't load settings:", err);
+  }
+}
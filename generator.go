@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/RLBot/go-interface/flat"
+)
+
+// words in the names of items that belong to a theme, matched as whole words
+var loadoutThemes = map[string][]string{
+	"halloween": {"halloween", "haunted", "spooky", "pumpkin", "skull", "ghost", "zombie", "witch", "bone"},
+	"winter":    {"winter", "frost", "snow", "holiday", "santa", "ice", "candy cane", "gingerbread"},
+	"space":     {"space", "galaxy", "cosmic", "alien", "planet", "nebula", "orbit"},
+	"fire":      {"fire", "flame", "inferno", "lava", "dragon", "blaze", "magma", "ember"},
+	"retro":     {"retro", "synthwave", "80s", "neon", "arcade", "pixel", "cassette", "vhs"},
+	"nature":    {"leaf", "tree", "flower", "jungle", "bamboo", "forest", "cactus", "mushroom"},
+	"esports":   {"rlcs", "esports", "championship"},
+}
+
+type LoadoutConstraints struct {
+	// Keep this car body, 0 picks a random one
+	CarId uint32 `json:"carId"`
+	// Team colors to pick from, empty allows all of them
+	TeamColorIds   []uint32 `json:"teamColorIds"`
+	CustomColorIds []uint32 `json:"customColorIds"`
+	// Prefer items of this theme, empty allows all items
+	Theme string `json:"theme"`
+	// Only pick items that can come certified, slots without such items are left empty.
+	// Needs an item database with certification data.
+	OnlyCertified bool `json:"onlyCertified"`
+}
+
+func (constraints LoadoutConstraints) Validate(db *ItemDatabase) error {
+	if constraints.CarId != 0 {
+		car, ok := db.Get(constraints.CarId)
+		if !ok || car.Category != "Body" {
+			return fmt.Errorf("%d isn't a car body", constraints.CarId)
+		}
+	}
+
+	for _, id := range constraints.TeamColorIds {
+		if id >= teamColorCount {
+			return fmt.Errorf("team color %d doesn't exist", id)
+		}
+	}
+	for _, id := range constraints.CustomColorIds {
+		if id >= customColorCount {
+			return fmt.Errorf("custom color %d doesn't exist", id)
+		}
+	}
+
+	if _, ok := loadoutThemes[constraints.Theme]; constraints.Theme != "" && !ok {
+		return errors.New("unknown theme: " + constraints.Theme)
+	}
+
+	if constraints.OnlyCertified && !db.HasCertifications() {
+		return errors.New("the item database doesn't know which items can come certified, update it with a json dump that has \"certifiable\"")
+	}
+
+	return nil
+}
+
+// LoadoutGenerator picks random items from an item database
+type LoadoutGenerator struct {
+	db  *ItemDatabase
+	rng *rand.Rand
+}
+
+func NewLoadoutGenerator(db *ItemDatabase) *LoadoutGenerator {
+	return &LoadoutGenerator{db, rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))}
+}
+
+// matchesTheme reports if the name of item has one of keywords as whole words,
+// so that "ice" doesn't match "Dice" and "candy cane" needs both words in a row
+func matchesTheme(item Item, keywords []string) bool {
+	words := strings.FieldsFunc(strings.ToLower(item.Name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, keyword := range keywords {
+		keywordWords := strings.Fields(keyword)
+		for i := 0; i+len(keywordWords) <= len(words); i++ {
+			if slices.Equal(words[i:i+len(keywordWords)], keywordWords) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// pick returns a random item from items, preferring the ones that match the theme.
+// Returns 0 if items is empty.
+func (g *LoadoutGenerator) pick(items []Item, theme string) uint32 {
+	if keywords, ok := loadoutThemes[theme]; ok {
+		themed := []Item{}
+		for _, item := range items {
+			if matchesTheme(item, keywords) {
+				themed = append(themed, item)
+			}
+		}
+
+		// not every slot has items for every theme
+		if len(themed) > 0 {
+			items = themed
+		}
+	}
+
+	if len(items) == 0 {
+		return 0
+	}
+
+	return items[g.rng.IntN(len(items))].Id
+}
+
+// items returns the items of category that constraints allow
+func (g *LoadoutGenerator) items(category string, constraints LoadoutConstraints) []Item {
+	items := g.db.Category(category)
+	if !constraints.OnlyCertified {
+		return items
+	}
+
+	return slices.DeleteFunc(slices.Clone(items), func(item Item) bool {
+		return !item.Certifiable
+	})
+}
+
+func (g *LoadoutGenerator) pickColor(allowed []uint32, count uint32) uint32 {
+	if len(allowed) > 0 {
+		return allowed[g.rng.IntN(len(allowed))]
+	}
+
+	return g.rng.Uint32N(count)
+}
+
+// Generate returns a random loadout, with the same items for both teams
+func (g *LoadoutGenerator) Generate(constraints LoadoutConstraints) (LoadoutConfig, error) {
+	if err := constraints.Validate(g.db); err != nil {
+		return LoadoutConfig{}, err
+	}
+
+	var loadout TeamLoadoutConfig
+	theme := constraints.Theme
+
+	loadout.CarId = constraints.CarId
+	if loadout.CarId == 0 {
+		loadout.CarId = g.pick(g.items("Body", constraints), theme)
+	}
+	if loadout.CarId == 0 {
+		// every loadout needs a car
+		loadout.CarId = g.pick(g.db.Category("Body"), theme)
+	}
+
+	car, _ := g.db.Get(loadout.CarId)
+	decals := []Item{}
+	for _, decal := range g.items("Skin", constraints) {
+		if body := g.db.decalBody(decal); body == "" || body == car.Name {
+			decals = append(decals, decal)
+		}
+	}
+
+	loadout.DecalId = g.pick(decals, theme)
+	loadout.WheelsId = g.pick(g.items("Wheels", constraints), theme)
+	loadout.BoostId = g.pick(g.items("Boost", constraints), theme)
+	loadout.AntennaId = g.pick(g.items("Antenna", constraints), theme)
+	loadout.HatId = g.pick(g.items("Hat", constraints), theme)
+	loadout.PaintFinishId = g.pick(g.items("PaintFinish", constraints), theme)
+	loadout.CustomFinishId = g.pick(g.items("PaintFinish", constraints), theme)
+	loadout.EngineAudioId = g.pick(g.items("EngineAudio", constraints), theme)
+	loadout.TrailsId = g.pick(g.items("SupersonicTrail", constraints), theme)
+	loadout.GoalExplosionId = g.pick(g.items("GoalExplosion", constraints), theme)
+
+	for _, slot := range teamLoadoutSlots(&loadout) {
+		if slot.paint != nil {
+			*slot.paint = g.rng.Uint32N(maxPaintId + 1)
+		}
+	}
+
+	loadout.CustomColorId = g.pickColor(constraints.CustomColorIds, customColorCount)
+
+	blue, orange := loadout, loadout
+	blue.TeamColorId = g.pickColor(constraints.TeamColorIds, teamColorCount)
+	orange.TeamColorId = g.pickColor(constraints.TeamColorIds, teamColorCount)
+
+	return LoadoutConfig{blue, orange}, nil
+}
+
+// randomizeMissingLoadouts gives every bot in match that has no loadout a random one
+func randomizeMissingLoadouts(match *flat.MatchConfigurationT, constraints LoadoutConstraints) error {
+	generator := NewLoadoutGenerator(Items())
+
+	for _, player := range match.PlayerConfigurations {
+		if player.Variety == nil {
+			continue
+		}
+
+		var loadout **flat.PlayerLoadoutT
+		switch variety := player.Variety.Value.(type) {
+		case *flat.CustomBotT:
+			loadout = &variety.Loadout
+		case *flat.PsyonixBotT:
+			loadout = &variety.Loadout
+		default:
+			continue
+		}
+
+		if *loadout != nil {
+			continue
+		}
+
+		generated, err := generator.Generate(constraints)
+		if err != nil {
+			return err
+		}
+
+		if player.Team == 0 {
+			*loadout = generated.Blue.ToPlayerLoadout()
+		} else {
+			*loadout = generated.Orange.ToPlayerLoadout()
+		}
+	}
+
+	return nil
+}
+
+func (a *App) GetLoadoutThemes() []string {
+	return slices.Sorted(maps.Keys(loadoutThemes))
+}
+
+func (a *App) GenerateLoadout(constraints LoadoutConstraints) (LoadoutConfig, error) {
+	return NewLoadoutGenerator(Items()).Generate(constraints)
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed frontend/src/assets/items.csv
+var itemsCsv string
+
+type Item struct {
+	Id       uint32 `json:"id"`
+	Category string `json:"category"`
+	Uuid     string `json:"uuid"`
+	Name     string `json:"name"`
+	// The item can come certified. Only json dumps have this, items.csv doesn't.
+	Certifiable bool `json:"certifiable"`
+}
+
+// these are "Black Market Drop", "Exotic Drop" etc that have the category "Body" for some reason
+var excludedItems = []uint32{5364, 5365, 5366, 5367, 5368, 5369}
+
+const (
+	maxPaintId       = 18
+	teamColorCount   = 70
+	customColorCount = 105
+)
+
+type ItemDatabase struct {
+	items map[uint32]Item
+	// items of each category, sorted by name
+	categories map[string][]Item
+	// names of the car bodies, to find the ones decals are made for
+	bodies map[string]bool
+}
+
+func newItemDatabase() *ItemDatabase {
+	return &ItemDatabase{
+		items:      map[uint32]Item{},
+		categories: map[string][]Item{},
+		bodies:     map[string]bool{},
+	}
+}
+
+func (db *ItemDatabase) add(item Item) {
+	if slices.Contains(excludedItems, item.Id) {
+		return
+	}
+
+	db.items[item.Id] = item
+	db.categories[item.Category] = append(db.categories[item.Category], item)
+	if item.Category == "Body" {
+		db.bodies[item.Name] = true
+	}
+}
+
+func (db *ItemDatabase) sort() {
+	for _, items := range db.categories {
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Name < items[j].Name
+		})
+	}
+}
+
+func ParseItemsCsv(reader io.Reader) (*ItemDatabase, error) {
+	r := csv.NewReader(reader)
+	// some names contain quotes that aren't escaped
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+
+	db := newItemDatabase()
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(record) < 4 {
+			continue
+		}
+
+		id, err := strconv.ParseUint(record[0], 10, 32)
+		if err != nil {
+			continue
+		}
+
+		db.add(Item{
+			Id:       uint32(id),
+			Category: record[1],
+			Uuid:     record[2],
+			// names with commas are split over multiple columns
+			Name: strings.Join(record[3:], ","),
+		})
+	}
+
+	db.sort()
+	return db, nil
+}
+
+var (
+	embeddedItems     *ItemDatabase
+	embeddedItemsOnce sync.Once
+)
+
+// EmbeddedItems is the item database that ships with the GUI
+func EmbeddedItems() *ItemDatabase {
+	embeddedItemsOnce.Do(func() {
+		db, err := ParseItemsCsv(strings.NewReader(itemsCsv))
+		if err != nil {
+			println("WARN: failed to parse embedded items.csv: " + err.Error())
+			db = newItemDatabase()
+		}
+		embeddedItems = db
+	})
+
+	return embeddedItems
+}
+
+var (
+	activeItems   *ItemDatabase
+	activeItemsMu sync.RWMutex
+)
+
+// Items is the item database used by the GUI,
+// the embedded one unless the user updated it
+func Items() *ItemDatabase {
+	activeItemsMu.RLock()
+	db := activeItems
+	activeItemsMu.RUnlock()
+
+	if db == nil {
+		return EmbeddedItems()
+	}
+
+	return db
+}
+
+func setItems(db *ItemDatabase) {
+	activeItemsMu.Lock()
+	defer activeItemsMu.Unlock()
+
+	activeItems = db
+}
+
+// All returns every item, sorted by id
+func (db *ItemDatabase) All() []Item {
+	items := slices.Collect(maps.Values(db.items))
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Id < items[j].Id
+	})
+
+	return items
+}
+
+// WriteCsv writes db in the format of the embedded items.csv.
+// Like in that file, commas in names aren't escaped.
+func (db *ItemDatabase) WriteCsv(w io.Writer) error {
+	for _, item := range db.All() {
+		if _, err := fmt.Fprintf(w, "%d,%s,%s,%s\n", item.Id, item.Category, item.Uuid, item.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HasCertifications reports if db knows which items can come certified
+func (db *ItemDatabase) HasCertifications() bool {
+	for _, item := range db.items {
+		if item.Certifiable {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (db *ItemDatabase) Get(id uint32) (Item, bool) {
+	item, ok := db.items[id]
+	return item, ok
+}
+
+func (db *ItemDatabase) Category(category string) []Item {
+	return db.categories[category]
+}
+
+// Search finds the items whose name contains query, ignoring case.
+// An empty category searches all categories, a limit of 0 means no limit.
+func (db *ItemDatabase) Search(query string, category string, limit int) []Item {
+	query = strings.ToLower(strings.TrimSpace(query))
+	results := []Item{}
+
+	categories := []string{category}
+	if category == "" {
+		categories = slices.Sorted(maps.Keys(db.categories))
+	}
+
+	for _, category := range categories {
+		for _, item := range db.categories[category] {
+			if !strings.Contains(strings.ToLower(item.Name), query) {
+				continue
+			}
+
+			results = append(results, item)
+			if limit > 0 && len(results) >= limit {
+				return results
+			}
+		}
+	}
+
+	return results
+}
+
+// decalBody returns the name of the body a decal is made for, like Octane for "Octane: Flames",
+// or "" if the decal fits every body. Names like "Fire God: Ember" don't start with a body.
+func (db *ItemDatabase) decalBody(decal Item) string {
+	body, _, found := strings.Cut(decal.Name, ": ")
+	if !found || !db.bodies[body] {
+		return ""
+	}
+
+	return body
+}
+
+type loadoutSlot struct {
+	name     string
+	category string
+	id       uint32
+	// nil if the slot can't be painted
+	paint *uint32
+}
+
+func teamLoadoutSlots(loadout *TeamLoadoutConfig) []loadoutSlot {
+	return []loadoutSlot{
+		{"car", "Body", loadout.CarId, &loadout.Paint.CarPaintId},
+		{"decal", "Skin", loadout.DecalId, &loadout.Paint.DecalPaintId},
+		{"wheels", "Wheels", loadout.WheelsId, &loadout.Paint.WheelsPaintId},
+		{"boost", "Boost", loadout.BoostId, &loadout.Paint.BoostPaintId},
+		{"antenna", "Antenna", loadout.AntennaId, &loadout.Paint.AntennaPaintId},
+		{"hat", "Hat", loadout.HatId, &loadout.Paint.HatPaintId},
+		{"paint finish", "PaintFinish", loadout.PaintFinishId, nil},
+		{"custom finish", "PaintFinish", loadout.CustomFinishId, nil},
+		{"engine audio", "EngineAudio", loadout.EngineAudioId, nil},
+		{"trails", "SupersonicTrail", loadout.TrailsId, &loadout.Paint.TrailsPaintId},
+		{"goal explosion", "GoalExplosion", loadout.GoalExplosionId, &loadout.Paint.GoalExplosionPaintId},
+	}
+}
+
+// CheckTeamLoadout lists the problems of loadout:
+// items that don't exist or are in the wrong slot, decals made for another car,
+// and paints or colors out of range.
+// An id of 0 means the slot is empty and is always allowed.
+func (db *ItemDatabase) CheckTeamLoadout(loadout TeamLoadoutConfig) []string {
+	problems, unknown := db.checkTeamLoadout(loadout)
+	return append(problems, unknown...)
+}
+
+// checkTeamLoadout is CheckTeamLoadout with the items that aren't in the database apart,
+// they may be newer than the database and work in the game
+func (db *ItemDatabase) checkTeamLoadout(loadout TeamLoadoutConfig) ([]string, []string) {
+	problems := []string{}
+	unknown := []string{}
+
+	if loadout.TeamColorId >= teamColorCount {
+		problems = append(problems, fmt.Sprintf("team color %d doesn't exist", loadout.TeamColorId))
+	}
+	if loadout.CustomColorId >= customColorCount {
+		problems = append(problems, fmt.Sprintf("custom color %d doesn't exist", loadout.CustomColorId))
+	}
+
+	for _, slot := range teamLoadoutSlots(&loadout) {
+		if slot.paint != nil && *slot.paint > maxPaintId {
+			problems = append(problems, fmt.Sprintf("%s: paint %d doesn't exist", slot.name, *slot.paint))
+		}
+
+		if slot.id == 0 {
+			continue
+		}
+
+		item, ok := db.Get(slot.id)
+		if !ok {
+			unknown = append(unknown, fmt.Sprintf("%s: unknown item %d", slot.name, slot.id))
+		} else if item.Category != slot.category {
+			problems = append(problems, fmt.Sprintf(
+				"%s: %q is a %s, not a %s", slot.name, item.Name, item.Category, slot.category,
+			))
+		}
+	}
+
+	decal, hasDecal := db.Get(loadout.DecalId)
+	car, hasCar := db.Get(loadout.CarId)
+	if hasDecal && hasCar && decal.Category == "Skin" {
+		if body := db.decalBody(decal); body != "" && body != car.Name {
+			problems = append(problems, fmt.Sprintf("decal: %q doesn't fit the %s", decal.Name, car.Name))
+		}
+	}
+
+	return problems, unknown
+}
+
+// CheckLoadout lists the problems of both teams' loadouts
+func (db *ItemDatabase) CheckLoadout(loadout LoadoutConfig) []string {
+	problems := []string{}
+
+	for _, problem := range db.CheckTeamLoadout(loadout.Blue) {
+		problems = append(problems, "blue "+problem)
+	}
+	for _, problem := range db.CheckTeamLoadout(loadout.Orange) {
+		problems = append(problems, "orange "+problem)
+	}
+
+	return problems
+}
+
+func (a *App) GetItem(id uint32) (Item, error) {
+	item, ok := Items().Get(id)
+	if !ok {
+		return Item{}, fmt.Errorf("unknown item %d", id)
+	}
+
+	return item, nil
+}
+
+// GetItemsCsv returns the item database for the loadout editor
+func (a *App) GetItemsCsv() (string, error) {
+	var builder strings.Builder
+	err := Items().WriteCsv(&builder)
+	return builder.String(), err
+}
+
+func (a *App) SearchItems(query string, category string, limit int) []Item {
+	return Items().Search(query, category, limit)
+}
+
+func (a *App) ValidateLoadout(loadout LoadoutConfig) []string {
+	return Items().CheckLoadout(loadout)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const itemsFetchTimeout = 30 * time.Second
+
+type ItemsUpdateReport struct {
+	Source string `json:"source"`
+	Added  []Item `json:"added"`
+	// Items of the current database that the dump doesn't have. They're kept,
+	// since the dump is merged into the embedded items.
+	MissingFromDump []Item `json:"missingFromDump"`
+	// Items whose name, category or certifiability changed, with their new values
+	Changed []Item `json:"changed"`
+	Total   int    `json:"total"`
+}
+
+// ParseItemsJson reads a json dump of items, either an array of items
+// or an object with the array in "items"
+func ParseItemsJson(data []byte) (*ItemDatabase, error) {
+	var items []Item
+	if err := json.Unmarshal(data, &items); err != nil {
+		var wrapped struct {
+			Items []Item `json:"items"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, err
+		}
+		items = wrapped.Items
+	}
+
+	db := newItemDatabase()
+	for _, item := range items {
+		if item.Id == 0 || item.Category == "" {
+			continue
+		}
+		db.add(item)
+	}
+
+	db.sort()
+	return db, nil
+}
+
+// ParseItemsDump reads a json or csv dump of items
+func ParseItemsDump(data []byte) (*ItemDatabase, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return ParseItemsJson(trimmed)
+	}
+
+	return ParseItemsCsv(bytes.NewReader(data))
+}
+
+// readItemsDump reads source, which is either a local path or an http(s) url
+func readItemsDump(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+
+	client := http.Client{Timeout: itemsFetchTimeout}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s failed: %s", source, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// MergeItems returns the items of base and update, preferring the ones in update
+func MergeItems(base *ItemDatabase, update *ItemDatabase) *ItemDatabase {
+	merged := newItemDatabase()
+	for _, item := range base.All() {
+		if _, ok := update.Get(item.Id); !ok {
+			merged.add(item)
+		}
+	}
+	for _, item := range update.All() {
+		merged.add(item)
+	}
+
+	merged.sort()
+	return merged
+}
+
+// compareItems reports how update differs from current
+func compareItems(current *ItemDatabase, update *ItemDatabase) ItemsUpdateReport {
+	report := ItemsUpdateReport{
+		Added:           []Item{},
+		MissingFromDump: []Item{},
+		Changed:         []Item{},
+		Total:           len(update.items),
+	}
+
+	for _, item := range update.All() {
+		old, ok := current.Get(item.Id)
+		if !ok {
+			report.Added = append(report.Added, item)
+		} else if old.Name != item.Name || old.Category != item.Category || old.Certifiable != item.Certifiable {
+			report.Changed = append(report.Changed, item)
+		}
+	}
+
+	for _, item := range current.All() {
+		if _, ok := update.Get(item.Id); !ok {
+			report.MissingFromDump = append(report.MissingFromDump, item)
+		}
+	}
+
+	return report
+}
+
+// json, so that the certifiable items of json dumps are kept
+func (a *App) itemsPath() string {
+	return filepath.Join(a.GetDefaultPath(), "items.json")
+}
+
+// loadUserItems uses the item database saved by UpdateItemDatabase at path, if there is one
+func loadUserItems(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	db, err := ParseItemsDump(data)
+	if err != nil {
+		println("WARN: ignoring updated item database at " + path + ": " + err.Error())
+		return
+	}
+
+	setItems(MergeItems(EmbeddedItems(), db))
+}
+
+// UpdateItemDatabase merges the items of a newer dump into the embedded item database.
+// source is a local path or an http(s) url to a csv in the format of items.csv,
+// or a json array of items, which can mark the items that can come certified.
+func (a *App) UpdateItemDatabase(source string) (ItemsUpdateReport, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return ItemsUpdateReport{}, errors.New("no item dump given")
+	}
+
+	data, err := readItemsDump(source)
+	if err != nil {
+		return ItemsUpdateReport{}, err
+	}
+
+	dump, err := ParseItemsDump(data)
+	if err != nil {
+		return ItemsUpdateReport{}, err
+	}
+	if len(dump.items) == 0 {
+		return ItemsUpdateReport{}, errors.New("the dump at " + source + " has no items")
+	}
+
+	merged := MergeItems(EmbeddedItems(), dump)
+	report := compareItems(Items(), merged)
+	// merged still has every embedded item, what's missing is about the dump
+	report.MissingFromDump = compareItems(Items(), dump).MissingFromDump
+	report.Source = source
+
+	if err := os.MkdirAll(filepath.Dir(a.itemsPath()), 0755); err != nil {
+		return report, err
+	}
+
+	data, err = json.Marshal(merged.All())
+	if err != nil {
+		return report, err
+	}
+	if err := os.WriteFile(a.itemsPath(), data, 0644); err != nil {
+		return report, err
+	}
+
+	setItems(merged)
+	emitEvent("items-updated", report.Total)
+	return report, nil
+}
+
+// ResetItemDatabase goes back to the item database that ships with the GUI
+func (a *App) ResetItemDatabase() error {
+	err := os.Remove(a.itemsPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	setItems(nil)
+	emitEvent("items-updated", len(Items().items))
+	return nil
+}
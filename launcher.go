@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/RLBot/go-interface/flat"
+)
+
+const (
+	LauncherSteam     = "steam"
+	LauncherEpic      = "epic"
+	LauncherLegendary = "legendary"
+	LauncherHeroic    = "heroic"
+	LauncherCustom    = "custom"
+	LauncherNoLaunch  = "nolaunch"
+)
+
+// launchers that RLBotServer starts through LauncherCustom, with their name as the argument
+var customLaunchers = []string{LauncherLegendary, LauncherHeroic}
+
+var errNoLauncher = errors.New("no launcher specified")
+
+type LauncherInfo struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	// If the launcher was found on this machine, always true for nolaunch and custom
+	Installed bool `json:"installed"`
+	// Where the launcher was found
+	Path string `json:"path"`
+}
+
+// ResolveLauncher turns the launcher chosen in the GUI into what RLBotServer expects
+func ResolveLauncher(launcher string, launcherArg string) (flat.Launcher, string, error) {
+	launcherArg = strings.TrimSpace(launcherArg)
+	if strings.ContainsAny(launcherArg, "\r\n\x00") {
+		return 0, "", errors.New("launcher argument can't contain line breaks")
+	}
+
+	switch launcher {
+	case LauncherSteam:
+		return flat.LauncherSteam, "", nil
+	case LauncherEpic:
+		return flat.LauncherEpic, "", nil
+	case LauncherLegendary, LauncherHeroic:
+		return flat.LauncherCustom, launcher, nil
+	case LauncherCustom:
+		if launcherArg == "" {
+			return 0, "", errors.New("no custom launcher chosen, use one of: " + strings.Join(customLaunchers, ", "))
+		}
+		if !slices.Contains(customLaunchers, launcherArg) {
+			return 0, "", errors.New(
+				"unsupported custom launcher " + launcherArg + ", use one of: " + strings.Join(customLaunchers, ", "),
+			)
+		}
+		return flat.LauncherCustom, launcherArg, nil
+	case LauncherNoLaunch:
+		return flat.LauncherNoLaunch, "", nil
+	case "":
+		return 0, "", errNoLauncher
+	default:
+		return 0, "", errors.New("unknown launcher: " + launcher)
+	}
+}
+
+// resolveLauncherOrNoLaunch is ResolveLauncher, but not choosing a launcher means not launching the game
+func resolveLauncherOrNoLaunch(launcher string, launcherArg string) (flat.Launcher, string, error) {
+	flatLauncher, arg, err := ResolveLauncher(launcher, launcherArg)
+	if errors.Is(err, errNoLauncher) {
+		println("No launcher chosen, defaulting to NoLaunch")
+		return flat.LauncherNoLaunch, "", nil
+	}
+
+	return flatLauncher, arg, err
+}
+
+func firstExisting(paths ...string) string {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+func findSteam() string {
+	if runtime.GOOS == "windows" {
+		return firstExisting(
+			filepath.Join(os.Getenv("ProgramFiles(x86)"), "Steam", "steam.exe"),
+			filepath.Join(os.Getenv("ProgramFiles"), "Steam", "steam.exe"),
+		)
+	}
+
+	if path, err := exec.LookPath("steam"); err == nil {
+		return path
+	}
+
+	return firstExisting(steamRoot())
+}
+
+func findEpic() string {
+	if runtime.GOOS != "windows" {
+		// linux users play epic games through legendary or heroic
+		return ""
+	}
+
+	return firstExisting(
+		filepath.Join(os.Getenv("ProgramFiles(x86)"), "Epic Games", "Launcher", "Portal", "Binaries", "Win64", "EpicGamesLauncher.exe"),
+		filepath.Join(os.Getenv("ProgramFiles"), "Epic Games", "Launcher", "Portal", "Binaries", "Win64", "EpicGamesLauncher.exe"),
+	)
+}
+
+func findLegendary() string {
+	if path, err := exec.LookPath("legendary"); err == nil {
+		return path
+	}
+
+	return ""
+}
+
+func findHeroic() string {
+	if path, err := exec.LookPath("heroic"); err == nil {
+		return path
+	}
+
+	home, _ := os.UserHomeDir()
+	if runtime.GOOS == "windows" {
+		return firstExisting(filepath.Join(os.Getenv("LOCALAPPDATA"), "Programs", "heroic", "Heroic.exe"))
+	}
+
+	return firstExisting(
+		filepath.Join(home, ".var/app/com.heroicgameslauncher.hgl"),
+		"/opt/Heroic/heroic",
+	)
+}
+
+// DetectLaunchers lists the launchers the GUI supports and whether they're installed
+func DetectLaunchers() []LauncherInfo {
+	launchers := []LauncherInfo{
+		{Id: LauncherSteam, Name: "Steam", Path: findSteam()},
+		{Id: LauncherEpic, Name: "Epic", Path: findEpic()},
+		{Id: LauncherLegendary, Name: "Legendary", Path: findLegendary()},
+		{Id: LauncherHeroic, Name: "Heroic", Path: findHeroic()},
+		{Id: LauncherCustom, Name: "Custom", Installed: true},
+		{Id: LauncherNoLaunch, Name: "Don't launch", Installed: true},
+	}
+
+	for i := range launchers {
+		if launchers[i].Path != "" {
+			launchers[i].Installed = true
+		}
+	}
+
+	return launchers
+}
+
+func (a *App) GetLaunchers() []LauncherInfo {
+	return DetectLaunchers()
+}
+
+func (a *App) ValidateLauncher(launcher string, launcherArg string) error {
+	_, _, err := ResolveLauncher(launcher, launcherArg)
+	return err
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+type LibraryLoadout struct {
+	Name     string        `json:"name"`
+	Tags     []string      `json:"tags"`
+	Modified time.Time     `json:"modified"`
+	Loadout  LoadoutConfig `json:"loadout"`
+}
+
+// libraryFile is the on-disk format of a library loadout.
+// It's a valid loadout.toml with an extra [library] table.
+type libraryFile struct {
+	Library struct {
+		Name string   `toml:"name"`
+		Tags []string `toml:"tags"`
+	} `toml:"library"`
+	Blue   TeamLoadoutConfig `toml:"blue_loadout"`
+	Orange TeamLoadoutConfig `toml:"orange_loadout"`
+}
+
+// LoadoutLibrary stores named loadouts that can be applied to any bot
+type LoadoutLibrary struct {
+	dir string
+}
+
+func NewLoadoutLibrary(dir string) *LoadoutLibrary {
+	return &LoadoutLibrary{dir}
+}
+
+func (l *LoadoutLibrary) path(name string) string {
+	return filepath.Join(l.dir, sanitizeFileName(name)+".toml")
+}
+
+func (l *LoadoutLibrary) overridesPath() string {
+	return filepath.Join(l.dir, "overrides.toml")
+}
+
+func readLibraryFile(path string) (LibraryLoadout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return LibraryLoadout{}, err
+	}
+
+	var file libraryFile
+	if _, err := toml.Decode(string(data), &file); err != nil {
+		return LibraryLoadout{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return LibraryLoadout{}, err
+	}
+
+	tags := file.Library.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return LibraryLoadout{
+		Name:     file.Library.Name,
+		Tags:     tags,
+		Modified: info.ModTime(),
+		Loadout:  LoadoutConfig{file.Blue, file.Orange},
+	}, nil
+}
+
+func (l *LoadoutLibrary) write(loadout LibraryLoadout) error {
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return err
+	}
+
+	var file libraryFile
+	file.Library.Name = loadout.Name
+	file.Library.Tags = loadout.Tags
+	file.Blue = loadout.Loadout.Blue
+	file.Orange = loadout.Loadout.Orange
+
+	data, err := toml.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(l.path(loadout.Name), data, 0644)
+}
+
+func (l *LoadoutLibrary) List() ([]LibraryLoadout, error) {
+	loadouts := []LibraryLoadout{}
+
+	paths, err := filepath.Glob(filepath.Join(l.dir, "*.toml"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		if path == l.overridesPath() {
+			continue
+		}
+
+		loadout, err := readLibraryFile(path)
+		if err != nil {
+			println("WARN: skipping library loadout at " + path)
+			continue
+		}
+		loadouts = append(loadouts, loadout)
+	}
+
+	sort.Slice(loadouts, func(i, j int) bool {
+		return strings.ToLower(loadouts[i].Name) < strings.ToLower(loadouts[j].Name)
+	})
+
+	return loadouts, nil
+}
+
+func (l *LoadoutLibrary) Get(name string) (LibraryLoadout, error) {
+	loadout, err := readLibraryFile(l.path(name))
+	// the file can belong to a loadout whose name sanitizes the same
+	if errors.Is(err, os.ErrNotExist) || err == nil && loadout.Name != name {
+		return LibraryLoadout{}, errors.New("no loadout named " + name)
+	}
+
+	return loadout, err
+}
+
+func (l *LoadoutLibrary) exists(name string) bool {
+	_, err := os.Stat(l.path(name))
+	return err == nil
+}
+
+// conflict returns the name of the other loadout that has the file of the loadout named name,
+// like "My Car?" for "My Car!"
+func (l *LoadoutLibrary) conflict(name string) (string, bool) {
+	existing, err := readLibraryFile(l.path(name))
+	if err != nil || existing.Name == name {
+		return "", false
+	}
+
+	return existing.Name, true
+}
+
+// sameFile reports if both names are stored in the same file,
+// which names that only differ in case are on case-insensitive filesystems
+func (l *LoadoutLibrary) sameFile(name string, otherName string) bool {
+	if l.path(name) == l.path(otherName) {
+		return true
+	}
+
+	info, err := os.Stat(l.path(name))
+	if err != nil {
+		return false
+	}
+	otherInfo, err := os.Stat(l.path(otherName))
+	return err == nil && os.SameFile(info, otherInfo)
+}
+
+func validateLoadoutName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("loadout name can't be empty")
+	}
+
+	if sanitizeFileName(name) == "overrides" {
+		return errors.New("loadout name is reserved: " + name)
+	}
+
+	return nil
+}
+
+func (l *LoadoutLibrary) Save(name string, loadout LoadoutConfig, tags []string) error {
+	if err := validateLoadoutName(name); err != nil {
+		return err
+	}
+
+	if other, ok := l.conflict(name); ok {
+		return errors.New("the name " + name + " is too similar to the loadout " + other)
+	}
+
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return l.write(LibraryLoadout{Name: name, Tags: tags, Loadout: loadout})
+}
+
+func (l *LoadoutLibrary) Duplicate(name string, newName string) error {
+	if err := validateLoadoutName(newName); err != nil {
+		return err
+	}
+
+	if other, ok := l.conflict(newName); ok {
+		return errors.New("the name " + newName + " is too similar to the loadout " + other)
+	}
+	if l.exists(newName) {
+		return errors.New("a loadout named " + newName + " already exists")
+	}
+
+	loadout, err := l.Get(name)
+	if err != nil {
+		return err
+	}
+
+	loadout.Name = newName
+	return l.write(loadout)
+}
+
+func (l *LoadoutLibrary) Rename(name string, newName string) error {
+	if !l.sameFile(name, newName) {
+		if err := l.Duplicate(name, newName); err != nil {
+			return err
+		}
+
+		if err := os.Remove(l.path(name)); err != nil {
+			return err
+		}
+	} else {
+		// only the display name changed
+		if err := validateLoadoutName(newName); err != nil {
+			return err
+		}
+
+		loadout, err := l.Get(name)
+		if err != nil {
+			return err
+		}
+
+		// give the file the case of the new name
+		if l.path(name) != l.path(newName) {
+			if err := os.Rename(l.path(name), l.path(newName)); err != nil {
+				return err
+			}
+		}
+
+		loadout.Name = newName
+		if err := l.write(loadout); err != nil {
+			return err
+		}
+	}
+
+	// keep bots that use the loadout pointing at it
+	overrides := l.Overrides()
+	for tomlPath, override := range overrides {
+		if override == name {
+			overrides[tomlPath] = newName
+		}
+	}
+
+	return l.writeOverrides(overrides)
+}
+
+func (l *LoadoutLibrary) SetTags(name string, tags []string) error {
+	loadout, err := l.Get(name)
+	if err != nil {
+		return err
+	}
+
+	slices.Sort(tags)
+	loadout.Tags = slices.Compact(tags)
+	return l.write(loadout)
+}
+
+func (l *LoadoutLibrary) Delete(name string) error {
+	if err := os.Remove(l.path(name)); err != nil {
+		return err
+	}
+
+	overrides := l.Overrides()
+	for tomlPath, override := range overrides {
+		if override == name {
+			delete(overrides, tomlPath)
+		}
+	}
+
+	return l.writeOverrides(overrides)
+}
+
+// Overrides maps the paths of bot.toml files to the name of the library loadout they use
+func (l *LoadoutLibrary) Overrides() map[string]string {
+	var file struct {
+		Bots map[string]string `toml:"bots"`
+	}
+
+	data, err := os.ReadFile(l.overridesPath())
+	if err == nil {
+		toml.Decode(string(data), &file)
+	}
+
+	if file.Bots == nil {
+		file.Bots = map[string]string{}
+	}
+
+	return file.Bots
+}
+
+func (l *LoadoutLibrary) writeOverrides(overrides map[string]string) error {
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := toml.Marshal(map[string]map[string]string{"bots": overrides})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(l.overridesPath(), data, 0644)
+}
+
+func (l *LoadoutLibrary) SetOverride(tomlPath string, name string) error {
+	overrides := l.Overrides()
+	if name == "" {
+		delete(overrides, tomlPath)
+	} else {
+		overrides[tomlPath] = name
+	}
+
+	return l.writeOverrides(overrides)
+}
+
+// applyOverride replaces the loadout of info if the user picked a library loadout for it
+func (l *LoadoutLibrary) applyOverride(info *BotInfo, overrides map[string]string) {
+	name, ok := overrides[info.TomlPath]
+	if !ok {
+		return
+	}
+
+	loadout, err := l.Get(name)
+	if err != nil {
+		println("WARN: bot " + info.TomlPath + " uses missing library loadout " + name)
+		return
+	}
+
+	info.Loadout = &loadout.Loadout
+	info.LoadoutOverride = name
+}
+
+func (a *App) ListLibraryLoadouts() ([]LibraryLoadout, error) {
+	return a.library.List()
+}
+
+func (a *App) SaveLibraryLoadout(name string, loadout LoadoutConfig, tags []string) error {
+	return a.library.Save(name, loadout, tags)
+}
+
+func (a *App) RenameLibraryLoadout(name string, newName string) error {
+	return a.library.Rename(name, newName)
+}
+
+func (a *App) DuplicateLibraryLoadout(name string, newName string) error {
+	return a.library.Duplicate(name, newName)
+}
+
+func (a *App) SetLibraryLoadoutTags(name string, tags []string) error {
+	return a.library.SetTags(name, tags)
+}
+
+func (a *App) DeleteLibraryLoadout(name string) error {
+	return a.library.Delete(name)
+}
+
+// ApplyLibraryLoadout gives the bot at tomlPath a library loadout.
+// With writeFile, the loadout is written to the bot's loadout_file,
+// otherwise the GUI remembers the choice without touching the bot's files.
+func (a *App) ApplyLibraryLoadout(name string, tomlPath string, writeFile bool) error {
+	loadout, err := a.library.Get(name)
+	if err != nil {
+		return err
+	}
+
+	if !writeFile {
+		return a.library.SetOverride(tomlPath, name)
+	}
+
+	data, err := os.ReadFile(tomlPath)
+	if err != nil {
+		return err
+	}
+
+	var conf BotConfig
+	if _, err := toml.Decode(string(data), &conf); err != nil {
+		return err
+	}
+
+	if conf.Settings.LoadoutFile == "" {
+		return errors.New("bot has no loadout_file, use an override instead")
+	}
+
+	if err := a.SaveLoadoutToFile(tomlPath, conf.Settings.LoadoutFile, loadout.Loadout); err != nil {
+		return err
+	}
+
+	// the file now has the loadout, an override would be redundant
+	return a.library.SetOverride(tomlPath, "")
+}
+
+// ClearLoadoutOverride makes the bot at tomlPath use its own loadout_file again
+func (a *App) ClearLoadoutOverride(tomlPath string) error {
+	return a.library.SetOverride(tomlPath, "")
+}
@@ -0,0 +1,116 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// ownCgroup returns the cgroup v2 directory the GUI itself runs in
+func ownCgroup() (string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return filepath.Join(cgroupRoot, path), nil
+		}
+	}
+
+	return "", errors.New("cgroup v2 is not available")
+}
+
+// cgroupLimits creates a cgroup next to the GUI's own one, which only works
+// if the cgroup tree was delegated to the user (e.g. a systemd user service).
+// It returns the opened cgroup directory, for SysProcAttr.CgroupFD.
+func cgroupLimits(name string, limits ResourceLimits) (*os.File, func(), error) {
+	own, err := ownCgroup()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dir := filepath.Join(filepath.Dir(own), "rlbotgui-"+sanitizeFileName(name))
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return nil, nil, err
+	}
+
+	remove := func() { os.Remove(dir) }
+
+	write := func(file, value string) error {
+		return os.WriteFile(filepath.Join(dir, file), []byte(value), 0644)
+	}
+
+	if limits.MaxMemoryMB != 0 {
+		if err := write("memory.max", strconv.FormatUint(limits.MaxMemoryMB*1024*1024, 10)); err != nil {
+			remove()
+			return nil, nil, err
+		}
+	}
+
+	if limits.MaxCpuPercent != 0 {
+		const period = 100000
+		quota := uint64(limits.MaxCpuPercent) * period / 100
+		if err := write("cpu.max", fmt.Sprintf("%d %d", quota, period)); err != nil {
+			remove()
+			return nil, nil, err
+		}
+	}
+
+	fd, err := os.Open(dir)
+	if err != nil {
+		remove()
+		return nil, nil, err
+	}
+
+	return fd, func() {
+		fd.Close()
+		remove()
+	}, nil
+}
+
+// rlimitCommand is the fallback when cgroups can't be used. Only memory can be limited this way,
+// by the shell before it runs the command, so that everything it starts inherits the limit.
+func rlimitCommand(cmd *exec.Cmd, limits ResourceLimits) error {
+	if limits.MaxMemoryMB != 0 {
+		last := len(cmd.Args) - 1
+		cmd.Args[last] = fmt.Sprintf("ulimit -v %d || exit 1\n", limits.MaxMemoryMB*1024) + cmd.Args[last]
+	}
+
+	if limits.MaxCpuPercent != 0 {
+		return errors.New("cpu limits require cgroup v2 delegation")
+	}
+
+	return nil
+}
+
+// limitProcess makes cmd, a shellCommand, start within limits, so that
+// the processes it starts are limited too. It returns a function
+// that should be called once the process exited.
+func limitProcess(cmd *exec.Cmd, name string, limits ResourceLimits) (func(), error) {
+	if !limits.Enabled() {
+		return func() {}, nil
+	}
+
+	fd, cleanup, err := cgroupLimits(name, limits)
+	if err == nil {
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = int(fd.Fd())
+		return cleanup, nil
+	}
+
+	return func() {}, rlimitCommand(cmd, limits)
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func limitProcess(cmd *exec.Cmd, name string, limits ResourceLimits) (func(), error) {
+	if !limits.Enabled() {
+		return func() {}, nil
+	}
+
+	return func() {}, errors.New("resource limits are only supported on Linux")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	rlbot "github.com/RLBot/go-interface"
@@ -59,21 +60,9 @@ func (options LoadoutPreviewOptions) GetPreviewMatch(existingMatchBehavior flat.
 		},
 	}
 
-	var launcher flat.Launcher
-	switch options.Launcher {
-	case "steam":
-		launcher = flat.LauncherSteam
-	case "epic":
-		launcher = flat.LauncherEpic
-	case "legendary", "heroic":
-		launcher = flat.LauncherCustom
-		options.LauncherArg = options.Launcher
-	case "custom":
-		launcher = flat.LauncherCustom
-	case "nolaunch":
-		launcher = flat.LauncherNoLaunch
-	default:
-		return nil, errors.New("no launcher specified")
+	launcher, launcherArg, err := ResolveLauncher(options.Launcher, options.LauncherArg)
+	if err != nil {
+		return nil, err
 	}
 
 	return &flat.MatchConfigurationT{
@@ -94,7 +83,7 @@ func (options LoadoutPreviewOptions) GetPreviewMatch(existingMatchBehavior flat.
 		SkipReplays:           true,
 		AutoSaveReplay:        false,
 		Launcher:              launcher,
-		LauncherArg:           options.LauncherArg,
+		LauncherArg:           launcherArg,
 		ExistingMatchBehavior: existingMatchBehavior,
 	}, nil
 }
@@ -105,7 +94,11 @@ func (a *App) LaunchPreviewLoadout(options LoadoutPreviewOptions, existingMatchB
 		return err
 	}
 
-	return StartAndWaitForMatch(a.rlbotAddress, match)
+	if err := a.ensureServer(); err != nil {
+		return err
+	}
+
+	return StartAndWaitForMatch(a.rlbotAddress(), match, nil)
 }
 
 func WaitForGamePacket(conn *rlbot.RLBotConnection) (*flat.GamePacketT, error) {
@@ -128,7 +121,16 @@ func WaitForGamePacket(conn *rlbot.RLBotConnection) (*flat.GamePacketT, error) {
 }
 
 func (a *App) SetLoadout(options LoadoutPreviewOptions) error {
-	conn, err := rlbot.Connect(a.rlbotAddress)
+	problems, unknown := Items().checkTeamLoadout(options.Loadout)
+	if len(problems) > 0 {
+		return errors.New("invalid loadout: " + strings.Join(problems, ", "))
+	}
+	if len(unknown) > 0 {
+		// items newer than the item database still work in the game
+		println("WARN: loadout has items the item database doesn't know: " + strings.Join(unknown, ", "))
+	}
+
+	conn, err := rlbot.Connect(a.rlbotAddress())
 	if err != nil {
 		return err
 	}
@@ -187,144 +189,6 @@ func (a *App) SetLoadout(options LoadoutPreviewOptions) error {
 	return nil
 }
 
-func StaticSetter(rlbotAddress string, team uint32) error {
-	conn, err := rlbot.Connect(rlbotAddress)
-	if err != nil {
-		return err
-	}
-
-	conn.SendPacket(&flat.ConnectionSettingsT{
-		AgentId:              "",
-		WantsBallPredictions: false,
-		WantsComms:           false,
-		CloseBetweenMatches:  true,
-	})
-
-	conn.SendPacket(&flat.InitCompleteT{})
-
-	gameState := flat.DesiredGameStateT{
-		CarStates: []*flat.DesiredCarStateT{
-			{
-				Physics: &flat.DesiredPhysicsT{
-					Location:        Vector3P(0, 0, 20),
-					Rotation:        RotatorP(0, 0, 0),
-					Velocity:        Vector3P(0, 0, 0),
-					AngularVelocity: Vector3P(0, 0, 0),
-				},
-			},
-		},
-	}
-
-	for {
-		packet, err := conn.RecvPacket()
-		if err != nil {
-			return err
-		}
-
-		switch packet.Value.(type) {
-		case *flat.DisconnectSignalT:
-			return nil
-		case *flat.GamePacketT:
-			conn.SendPacket(&gameState)
-		}
-	}
-}
-
-func (a *App) SetShowcaseType(showcaseType string, team uint32) error {
-	conn, err := rlbot.Connect(a.rlbotAddress)
-	if err != nil {
-		return err
-	}
-
-	conn.SendPacket(&flat.ConnectionSettingsT{
-		AgentId:              "",
-		WantsBallPredictions: false,
-		WantsComms:           false,
-		CloseBetweenMatches:  false,
-	})
-
-	conn.SendPacket(&flat.InitCompleteT{})
-
-	gamePacket, err := WaitForGamePacket(&conn)
-	if err != nil {
-		return err
-	}
-
-	ball := flat.DesiredPhysicsT{
-		Location:        Vector3P(0, 0, -100),
-		Velocity:        Vector3P(0, 0, 0),
-		AngularVelocity: Vector3P(0, 0, 0),
-	}
-
-	car := flat.DesiredPhysicsT{
-		Location:        Vector3P(0, 0, 20),
-		Rotation:        RotatorP(0, 0, 0),
-		Velocity:        Vector3P(0, 0, 0),
-		AngularVelocity: Vector3P(0, 0, 0),
-	}
-
-	controller := flat.ControllerStateT{}
-
-	var teamSign float32
-	if gamePacket.Players[0].Team == 0 {
-		teamSign = -1
-	} else {
-		teamSign = 1
-	}
-
-	// set initial game state
-	switch showcaseType {
-	case "static":
-		controller.Boost = true
-
-		go StaticSetter(a.rlbotAddress, team)
-	case "boost":
-		controller.Boost = true
-		controller.Steer = 1
-
-		car.Location.Y = Float(-1140)
-		car.Velocity.X = Float(2300)
-		car.AngularVelocity.Z = Float(3.5)
-	case "throttle":
-		controller.Throttle = 1
-		controller.Steer = 0.56
-
-		car.Location.Y = Float(-1140)
-		car.Velocity.X = Float(1410)
-		car.AngularVelocity.Z = Float(1.5)
-	case "back-center-kickoff":
-		car.Location.Y = Float(4608 * teamSign)
-		car.Rotation.Yaw = Float(-0.5 * 3.14159 * teamSign)
-	case "goal-explosion":
-		car.Location.Y = Float(-2000 * teamSign)
-		car.Rotation.Yaw = Float(-0.5 * 3.14159 * teamSign)
-		car.Velocity.Y = Float(-2300 * teamSign)
-		ball.Location = Vector3P(0, -3500*teamSign, 93)
-	}
-
-	conn.SendPacket(&flat.DesiredGameStateT{
-		CarStates: []*flat.DesiredCarStateT{
-			{
-				Physics: &car,
-			},
-		},
-		BallStates: []*flat.DesiredBallStateT{
-			{
-				Physics: &ball,
-			},
-		},
-	})
-
-	conn.SendPacket(&flat.PlayerInputT{
-		PlayerIndex:     0,
-		ControllerState: &controller,
-	})
-
-	conn.SendPacket(&flat.DisconnectSignalT{})
-
-	return nil
-}
-
 func Float(x float32) *flat.FloatT {
 	return &flat.FloatT{Val: x}
 }
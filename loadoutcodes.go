@@ -0,0 +1,338 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Loadout codes are the GUI's own short text format for sharing loadouts. The layout looks
+// like the codes of BakkesMod, but it was never checked against codes exported by BakkesMod,
+// so neither side is expected to read the codes of the other.
+// They're base64 encoded bit streams, least significant bit first.
+//
+//	header:  version (6) | payload size in bytes (10) | crc8 of the payload (8)
+//	payload: blue is orange (1) | blue team | orange team, unless blue is orange
+//	team:    item count (5) | items | override colors (1) | team color (7) | custom color (7)
+//	item:    slot (5) | product id (13) | paintable (1) | paint id (6), only if paintable
+const (
+	loadoutCodeVersion     = 2
+	loadoutCodeProductBits = 13
+)
+
+const (
+	codeSlotBody          = 0
+	codeSlotDecal         = 1
+	codeSlotWheels        = 2
+	codeSlotBoost         = 3
+	codeSlotAntenna       = 4
+	codeSlotTopper        = 5
+	codeSlotPaintFinish   = 7
+	codeSlotAccentFinish  = 12
+	codeSlotEngineAudio   = 13
+	codeSlotTrail         = 14
+	codeSlotGoalExplosion = 15
+)
+
+type codeItem struct {
+	slot    uint32
+	product *uint32
+	// nil if the slot can't be painted
+	paint *uint32
+}
+
+// codeItems maps the slots of a loadout code to the fields of loadout
+func codeItems(loadout *TeamLoadoutConfig) []codeItem {
+	return []codeItem{
+		{codeSlotBody, &loadout.CarId, &loadout.Paint.CarPaintId},
+		{codeSlotDecal, &loadout.DecalId, &loadout.Paint.DecalPaintId},
+		{codeSlotWheels, &loadout.WheelsId, &loadout.Paint.WheelsPaintId},
+		{codeSlotBoost, &loadout.BoostId, &loadout.Paint.BoostPaintId},
+		{codeSlotAntenna, &loadout.AntennaId, &loadout.Paint.AntennaPaintId},
+		{codeSlotTopper, &loadout.HatId, &loadout.Paint.HatPaintId},
+		{codeSlotPaintFinish, &loadout.PaintFinishId, nil},
+		{codeSlotAccentFinish, &loadout.CustomFinishId, nil},
+		{codeSlotEngineAudio, &loadout.EngineAudioId, nil},
+		{codeSlotTrail, &loadout.TrailsId, &loadout.Paint.TrailsPaintId},
+		{codeSlotGoalExplosion, &loadout.GoalExplosionId, &loadout.Paint.GoalExplosionPaintId},
+	}
+}
+
+type bitWriter struct {
+	data []byte
+	bits int
+}
+
+func (w *bitWriter) write(value uint32, count int) {
+	for i := range count {
+		if w.bits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if value&(1<<i) != 0 {
+			w.data[w.bits/8] |= 1 << (w.bits % 8)
+		}
+		w.bits++
+	}
+}
+
+func (w *bitWriter) writeBool(value bool) {
+	if value {
+		w.write(1, 1)
+	} else {
+		w.write(0, 1)
+	}
+}
+
+var errCodeTooShort = errors.New("loadout code is too short")
+
+type bitReader struct {
+	data []byte
+	bits int
+}
+
+func (r *bitReader) read(count int) (uint32, error) {
+	if r.bits+count > len(r.data)*8 {
+		return 0, errCodeTooShort
+	}
+
+	var value uint32
+	for i := range count {
+		if r.data[r.bits/8]&(1<<(r.bits%8)) != 0 {
+			value |= 1 << i
+		}
+		r.bits++
+	}
+
+	return value, nil
+}
+
+func (r *bitReader) readBool() (bool, error) {
+	value, err := r.read(1)
+	return value == 1, err
+}
+
+func crc8(data []byte) uint32 {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for range 8 {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return uint32(crc)
+}
+
+func writeCodeTeam(w *bitWriter, loadout TeamLoadoutConfig) {
+	items := []codeItem{}
+	for _, item := range codeItems(&loadout) {
+		if *item.product != 0 {
+			items = append(items, item)
+		}
+	}
+
+	w.write(uint32(len(items)), 5)
+	for _, item := range items {
+		w.write(item.slot, 5)
+		w.write(*item.product, loadoutCodeProductBits)
+		w.writeBool(item.paint != nil)
+		if item.paint != nil {
+			w.write(*item.paint, 6)
+		}
+	}
+
+	w.writeBool(true)
+	w.write(loadout.TeamColorId, 7)
+	w.write(loadout.CustomColorId, 7)
+}
+
+func readCodeTeam(r *bitReader) (TeamLoadoutConfig, error) {
+	var loadout TeamLoadoutConfig
+	slots := map[uint32]codeItem{}
+	for _, item := range codeItems(&loadout) {
+		slots[item.slot] = item
+	}
+
+	count, err := r.read(5)
+	if err != nil {
+		return loadout, err
+	}
+
+	for range count {
+		slot, err := r.read(5)
+		if err != nil {
+			return loadout, err
+		}
+		product, err := r.read(loadoutCodeProductBits)
+		if err != nil {
+			return loadout, err
+		}
+		paintable, err := r.readBool()
+		if err != nil {
+			return loadout, err
+		}
+		var paint uint32
+		if paintable {
+			if paint, err = r.read(6); err != nil {
+				return loadout, err
+			}
+		}
+
+		// slots the GUI doesn't know, like player banners, are dropped
+		item, ok := slots[slot]
+		if !ok {
+			continue
+		}
+
+		*item.product = product
+		if item.paint != nil {
+			*item.paint = paint
+		}
+	}
+
+	overrideColors, err := r.readBool()
+	if err != nil {
+		return loadout, err
+	}
+	if overrideColors {
+		if loadout.TeamColorId, err = r.read(7); err != nil {
+			return loadout, err
+		}
+		if loadout.CustomColorId, err = r.read(7); err != nil {
+			return loadout, err
+		}
+	}
+
+	return loadout, nil
+}
+
+// EncodeLoadoutCode turns loadout into a code that DecodeLoadoutCode reads
+func EncodeLoadoutCode(loadout LoadoutConfig) string {
+	var payload bitWriter
+	blueIsOrange := loadout.Blue == loadout.Orange
+	payload.writeBool(blueIsOrange)
+	writeCodeTeam(&payload, loadout.Blue)
+	if !blueIsOrange {
+		writeCodeTeam(&payload, loadout.Orange)
+	}
+
+	var header bitWriter
+	header.write(loadoutCodeVersion, 6)
+	header.write(uint32(len(payload.data)), 10)
+	header.write(crc8(payload.data), 8)
+
+	return base64.StdEncoding.EncodeToString(append(header.data, payload.data...))
+}
+
+func DecodeLoadoutCode(code string) (LoadoutConfig, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(code))
+	if err != nil {
+		return LoadoutConfig{}, fmt.Errorf("loadout code isn't valid base64: %w", err)
+	}
+
+	header := bitReader{data: data}
+	version, err := header.read(6)
+	if err != nil {
+		return LoadoutConfig{}, err
+	}
+	if version > loadoutCodeVersion {
+		return LoadoutConfig{}, fmt.Errorf("unsupported loadout code version %d", version)
+	}
+	size, err := header.read(10)
+	if err != nil {
+		return LoadoutConfig{}, err
+	}
+	crc, err := header.read(8)
+	if err != nil {
+		return LoadoutConfig{}, err
+	}
+
+	payload := data[3:]
+	if len(payload) < int(size) {
+		return LoadoutConfig{}, errCodeTooShort
+	}
+	payload = payload[:size]
+	if crc8(payload) != crc {
+		return LoadoutConfig{}, errors.New("loadout code checksum doesn't match, it might be incomplete")
+	}
+
+	r := bitReader{data: payload}
+	blueIsOrange, err := r.readBool()
+	if err != nil {
+		return LoadoutConfig{}, err
+	}
+
+	var loadout LoadoutConfig
+	if loadout.Blue, err = readCodeTeam(&r); err != nil {
+		return LoadoutConfig{}, err
+	}
+
+	if blueIsOrange {
+		loadout.Orange = loadout.Blue
+	} else if loadout.Orange, err = readCodeTeam(&r); err != nil {
+		return LoadoutConfig{}, err
+	}
+
+	return loadout, nil
+}
+
+// LoadoutImport is the result of importing a loadout.
+// Problems lists items that the game doesn't know, the loadout can still be used.
+type LoadoutImport struct {
+	Loadout  LoadoutConfig `json:"loadout"`
+	Problems []string      `json:"problems"`
+}
+
+func (a *App) ExportLoadoutCode(loadout LoadoutConfig) string {
+	return EncodeLoadoutCode(loadout)
+}
+
+func (a *App) ImportLoadoutCode(code string) (LoadoutImport, error) {
+	loadout, err := DecodeLoadoutCode(code)
+	if err != nil {
+		return LoadoutImport{}, err
+	}
+
+	return LoadoutImport{loadout, Items().CheckLoadout(loadout)}, nil
+}
+
+func (a *App) ExportLoadoutJson(loadout LoadoutConfig) (string, error) {
+	data, err := json.MarshalIndent(loadout, "", "  ")
+	return string(data), err
+}
+
+// ImportLoadoutJson reads a loadout in the format of ExportLoadoutJson.
+// A single team's loadout is used for both teams.
+func (a *App) ImportLoadoutJson(data string) (LoadoutImport, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return LoadoutImport{}, err
+	}
+
+	var loadout LoadoutConfig
+	_, hasBlue := fields["blueLoadout"]
+	_, hasOrange := fields["orangeLoadout"]
+	if hasBlue || hasOrange {
+		decoder := json.NewDecoder(strings.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&loadout); err != nil {
+			return LoadoutImport{}, err
+		}
+	} else {
+		var team TeamLoadoutConfig
+		decoder := json.NewDecoder(strings.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&team); err != nil {
+			return LoadoutImport{}, err
+		}
+		loadout = LoadoutConfig{team, team}
+	}
+
+	return LoadoutImport{loadout, Items().CheckLoadout(loadout)}, nil
+}
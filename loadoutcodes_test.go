@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testTeamLoadout() TeamLoadoutConfig {
+	return TeamLoadoutConfig{
+		TeamColorId:     27,
+		CustomColorId:   90,
+		CarId:           23,
+		DecalId:         306,
+		WheelsId:        1580,
+		BoostId:         35,
+		AntennaId:       1,
+		HatId:           228,
+		PaintFinishId:   270,
+		CustomFinishId:  1681,
+		EngineAudioId:   6919,
+		TrailsId:        32,
+		GoalExplosionId: 1903,
+		Paint: TeamPaintConfig{
+			CarPaintId:           12,
+			WheelsPaintId:        2,
+			GoalExplosionPaintId: 18,
+		},
+	}
+}
+
+func TestLoadoutCodeRoundTrip(t *testing.T) {
+	orange := testTeamLoadout()
+	orange.TeamColorId = 33
+	orange.Paint.CarPaintId = 0
+
+	for name, loadout := range map[string]LoadoutConfig{
+		"same teams":      {testTeamLoadout(), testTeamLoadout()},
+		"different teams": {testTeamLoadout(), orange},
+		"empty":           {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			decoded, err := DecodeLoadoutCode(EncodeLoadoutCode(loadout))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded != loadout {
+				t.Errorf("got %+v, want %+v", decoded, loadout)
+			}
+		})
+	}
+}
+
+func TestLoadoutCodeFormat(t *testing.T) {
+	// pins the layout, codes that were shared already have to keep working
+	const code = "wggBF7gAmSATAoLFFMYICJAAECjkIHAcAhZptIPNgYDAezulGy0="
+
+	loadout := LoadoutConfig{testTeamLoadout(), testTeamLoadout()}
+	if got := EncodeLoadoutCode(loadout); got != code {
+		t.Errorf("got code %s, want %s", got, code)
+	}
+
+	decoded, err := DecodeLoadoutCode(" " + code + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != loadout {
+		t.Errorf("got %+v, want %+v", decoded, loadout)
+	}
+}
+
+func TestLoadoutCodeUnknownSlot(t *testing.T) {
+	var payload bitWriter
+	payload.writeBool(true)
+	payload.write(2, 5)
+	// a slot the GUI doesn't know, like a player banner
+	payload.write(18, 5)
+	payload.write(4000, loadoutCodeProductBits)
+	payload.writeBool(false)
+	payload.write(codeSlotBody, 5)
+	payload.write(23, loadoutCodeProductBits)
+	payload.writeBool(true)
+	payload.write(3, 6)
+	payload.writeBool(false)
+
+	var header bitWriter
+	header.write(loadoutCodeVersion, 6)
+	header.write(uint32(len(payload.data)), 10)
+	header.write(crc8(payload.data), 8)
+
+	loadout, err := DecodeLoadoutCode(base64.StdEncoding.EncodeToString(append(header.data, payload.data...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := TeamLoadoutConfig{CarId: 23, Paint: TeamPaintConfig{CarPaintId: 3}}
+	if loadout.Blue != want || loadout.Orange != want {
+		t.Errorf("got %+v, want both teams to be %+v", loadout, want)
+	}
+}
+
+func TestLoadoutCodeInvalid(t *testing.T) {
+	code := EncodeLoadoutCode(LoadoutConfig{testTeamLoadout(), testTeamLoadout()})
+	data, _ := base64.StdEncoding.DecodeString(code)
+
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-1] ^= 0xff
+
+	newer := append([]byte{}, data...)
+	newer[0] = newer[0]&^0x3f | (loadoutCodeVersion + 1)
+
+	for _, test := range []struct {
+		name string
+		code string
+		want string
+	}{
+		{"not base64", "not a code!", "base64"},
+		{"empty", "", "too short"},
+		{"cut off", base64.StdEncoding.EncodeToString(data[:len(data)-4]), "too short"},
+		{"corrupted", base64.StdEncoding.EncodeToString(corrupted), "checksum"},
+		{"newer version", base64.StdEncoding.EncodeToString(newer), "version"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeLoadoutCode(test.code)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got %v, want an error about %q", err, test.want)
+			}
+		})
+	}
+}
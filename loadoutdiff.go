@@ -0,0 +1,114 @@
+package main
+
+import "fmt"
+
+type LoadoutFieldDiff struct {
+	// Like "decal" or "decal paint", prefixed with the team when diffing whole loadouts
+	Field string `json:"field"`
+	A     uint32 `json:"a"`
+	B     uint32 `json:"b"`
+	// Human readable versions of A and B, empty for paints since the paint names live in the frontend
+	AName string `json:"aName"`
+	BName string `json:"bName"`
+	// A and B are paint ids
+	Paint bool `json:"paint"`
+}
+
+func itemName(db *ItemDatabase, id uint32) string {
+	if id == 0 {
+		return "none"
+	}
+
+	if item, ok := db.Get(id); ok {
+		return item.Name
+	}
+
+	return fmt.Sprintf("unknown item %d", id)
+}
+
+// DiffTeamLoadouts lists the fields of a and b that differ, including paints and colors
+func DiffTeamLoadouts(db *ItemDatabase, a TeamLoadoutConfig, b TeamLoadoutConfig) []LoadoutFieldDiff {
+	diffs := []LoadoutFieldDiff{}
+
+	if a.TeamColorId != b.TeamColorId {
+		diffs = append(diffs, LoadoutFieldDiff{
+			Field: "team color",
+			A:     a.TeamColorId,
+			B:     b.TeamColorId,
+			AName: fmt.Sprintf("color %d", a.TeamColorId),
+			BName: fmt.Sprintf("color %d", b.TeamColorId),
+		})
+	}
+	if a.CustomColorId != b.CustomColorId {
+		diffs = append(diffs, LoadoutFieldDiff{
+			Field: "custom color",
+			A:     a.CustomColorId,
+			B:     b.CustomColorId,
+			AName: fmt.Sprintf("color %d", a.CustomColorId),
+			BName: fmt.Sprintf("color %d", b.CustomColorId),
+		})
+	}
+
+	slotsA, slotsB := teamLoadoutSlots(&a), teamLoadoutSlots(&b)
+	for i, slotA := range slotsA {
+		slotB := slotsB[i]
+
+		if slotA.id != slotB.id {
+			diffs = append(diffs, LoadoutFieldDiff{
+				Field: slotA.name,
+				A:     slotA.id,
+				B:     slotB.id,
+				AName: itemName(db, slotA.id),
+				BName: itemName(db, slotB.id),
+			})
+		}
+
+		if slotA.paint != nil && *slotA.paint != *slotB.paint {
+			diffs = append(diffs, LoadoutFieldDiff{
+				Field: slotA.name + " paint",
+				A:     *slotA.paint,
+				B:     *slotB.paint,
+				Paint: true,
+			})
+		}
+	}
+
+	return diffs
+}
+
+// DiffLoadouts compares the blue loadouts of a and b, and their orange loadouts
+func DiffLoadouts(db *ItemDatabase, a LoadoutConfig, b LoadoutConfig) []LoadoutFieldDiff {
+	diffs := []LoadoutFieldDiff{}
+
+	for _, diff := range DiffTeamLoadouts(db, a.Blue, b.Blue) {
+		diff.Field = "blue " + diff.Field
+		diffs = append(diffs, diff)
+	}
+	for _, diff := range DiffTeamLoadouts(db, a.Orange, b.Orange) {
+		diff.Field = "orange " + diff.Field
+		diffs = append(diffs, diff)
+	}
+
+	return diffs
+}
+
+// CopyBlueToOrange gives the orange team the items, paints and colors of the blue team.
+// Team color ids aren't translated: an id picks the color at that place of the palette of
+// the team that uses it, so the copy gets the orange color where blue had its blue one.
+func CopyBlueToOrange(loadout LoadoutConfig) LoadoutConfig {
+	loadout.Orange = loadout.Blue
+	return loadout
+}
+
+func (a *App) DiffLoadouts(first LoadoutConfig, second LoadoutConfig) []LoadoutFieldDiff {
+	return DiffLoadouts(Items(), first, second)
+}
+
+// DiffLoadoutTeams shows how the orange loadout differs from the blue one
+func (a *App) DiffLoadoutTeams(loadout LoadoutConfig) []LoadoutFieldDiff {
+	return DiffTeamLoadouts(Items(), loadout.Blue, loadout.Orange)
+}
+
+func (a *App) CopyBlueToOrange(loadout LoadoutConfig) LoadoutConfig {
+	return CopyBlueToOrange(loadout)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	defaultLogSize  = 1024 * 1024
+	defaultLogFiles = 3
+)
+
+// RotatingLog appends to a file, moving it to file.1, file.2, ...
+// once it grows larger than maxSize, keeping at most maxFiles old files
+type RotatingLog struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+func OpenRotatingLog(path string, maxSize int64, maxFiles int) (*RotatingLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	log := &RotatingLog{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+
+	if err := log.open(); err != nil {
+		return nil, err
+	}
+
+	return log, nil
+}
+
+func (l *RotatingLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+func (l *RotatingLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", l.path, l.maxFiles))
+	for i := l.maxFiles - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+	}
+
+	if l.maxFiles > 0 {
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return err
+		}
+	} else {
+		os.Remove(l.path)
+	}
+
+	return l.open()
+}
+
+func (l *RotatingLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if l.size > 0 && l.size+int64(len(p)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+func (l *RotatingLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// tailLines returns the last maxLines lines of the file at path
+func tailLines(path string, maxLines int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > maxLines {
+			lines = lines[1:]
+		}
+	}
+
+	return lines, scanner.Err()
+}
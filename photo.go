@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	rlbot "github.com/RLBot/go-interface"
+	"github.com/RLBot/go-interface/flat"
+	_ "golang.org/x/image/bmp"
+)
+
+const (
+	// time for the car to settle and the game to render the new loadout
+	photoSettleTime = 2 * time.Second
+	// time for the game to write the screenshot
+	photoTimeout = 15 * time.Second
+)
+
+// Console commands around the screenshot. The car cam ends up at the same spot behind the
+// posed car every time, and "Camera Fixed" keeps it there. ShowHUD toggles the HUD, and
+// its state can't be read, so it's hidden only if it was shown and isn't toggled back.
+// photoRestoreCommands only puts the camera back.
+var (
+	photoCommands        = []string{"Camera Fixed", "ShowHUD", "shot"}
+	photoRestoreCommands = []string{"Camera Default"}
+)
+
+type PhotoOptions struct {
+	Preview LoadoutPreviewOptions `json:"preview"`
+	// bot.toml of the bot that gets the picture
+	TomlPath string `json:"tomlPath"`
+	// Save the picture as the bot's logo instead of preview.png
+	AsLogo bool `json:"asLogo"`
+	// Yaw of the car in radians, 0 faces the side wall
+	Yaw float32 `json:"yaw"`
+	// Where the game saves screenshots, found automatically if empty
+	ScreenshotDir string `json:"screenshotDir"`
+}
+
+// photoScene holds the car still in the middle of the field with the ball out of the way
+func photoScene(yaw float32) ShowcaseScene {
+	return ShowcaseScene{
+		Id:   "photo",
+		Name: "Photo",
+		Keyframes: []ShowcaseKeyframe{{
+			Hold: true,
+			Car: &ShowcasePhysics{
+				Location:        &[3]float32{0, 0, 17},
+				Rotation:        &[3]float32{0, yaw, 0},
+				Velocity:        &[3]float32{},
+				AngularVelocity: &[3]float32{},
+			},
+			Ball: &ShowcasePhysics{
+				Location:        &[3]float32{0, 0, -100},
+				Velocity:        &[3]float32{},
+				AngularVelocity: &[3]float32{},
+			},
+			Controller: &ShowcaseController{},
+		}},
+	}
+}
+
+// screenshotDirs lists where Rocket League might save screenshots on this machine
+func screenshotDirs() []string {
+	const relative = "TAGame/ScreenShots"
+	home, _ := os.UserHomeDir()
+
+	if runtime.GOOS == "windows" {
+		return []string{
+			filepath.Join(os.Getenv("ProgramFiles(x86)"), "Steam/steamapps/common/rocketleague", relative),
+			filepath.Join(os.Getenv("ProgramFiles"), "Epic Games/rocketleague", relative),
+			filepath.Join(home, "Documents/My Games/Rocket League", relative),
+		}
+	}
+
+	steam := steamRoot()
+	return []string{
+		filepath.Join(steam, "steamapps/common/rocketleague", relative),
+		filepath.Join(steam, "steamapps/compatdata/252950/pfx/drive_c/users/steamuser/Documents/My Games/Rocket League", relative),
+		filepath.Join(home, "Games/Heroic/rocketleague", relative),
+		filepath.Join(home, "Games/rocketleague", relative),
+	}
+}
+
+// newestFile returns the most recently modified file in dirs that is newer than after
+func newestFile(dirs []string, after time.Time) string {
+	var newest string
+	var newestTime time.Time
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || entry.IsDir() {
+				continue
+			}
+
+			if info.ModTime().After(after) && info.ModTime().After(newestTime) {
+				newest = filepath.Join(dir, entry.Name())
+				newestTime = info.ModTime()
+			}
+		}
+	}
+
+	return newest
+}
+
+func sendConsoleCommands(rlbotAddress string, commands ...string) error {
+	conn, err := rlbot.Connect(rlbotAddress)
+	if err != nil {
+		return err
+	}
+
+	conn.SendPacket(&flat.ConnectionSettingsT{
+		AgentId:              "",
+		WantsBallPredictions: false,
+		WantsComms:           false,
+		CloseBetweenMatches:  true,
+	})
+	conn.SendPacket(&flat.InitCompleteT{})
+
+	consoleCommands := make([]*flat.ConsoleCommandT, len(commands))
+	for i, command := range commands {
+		consoleCommands[i] = &flat.ConsoleCommandT{Command: command}
+	}
+	conn.SendPacket(&flat.DesiredGameStateT{ConsoleCommands: consoleCommands})
+
+	conn.SendPacket(&flat.DisconnectSignalT{})
+	return nil
+}
+
+// savePng converts the screenshot at src to a png at dst
+func savePng(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	img, _, err := image.Decode(in)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return png.Encode(out, img)
+}
+
+// photoDestination returns where the picture of the bot at tomlPath is saved
+func photoDestination(tomlPath string, asLogo bool) (string, error) {
+	data, err := os.ReadFile(tomlPath)
+	if err != nil {
+		return "", err
+	}
+
+	var conf BotConfig
+	if _, err := toml.Decode(string(data), &conf); err != nil {
+		return "", err
+	}
+
+	rootDir := filepath.Join(filepath.Dir(tomlPath), conf.Settings.RootDir)
+	if !asLogo {
+		return filepath.Join(rootDir, "preview.png"), nil
+	}
+
+	// same lookup as GetBots
+	if conf.Settings.LogoFile != "" {
+		if !strings.EqualFold(filepath.Ext(conf.Settings.LogoFile), ".png") {
+			return "", errors.New("the bot's logo_file isn't a png")
+		}
+		return filepath.Join(rootDir, conf.Settings.LogoFile), nil
+	}
+
+	return filepath.Join(rootDir, "logo.png"), nil
+}
+
+// CaptureLoadoutPhoto poses the preview car, takes an in-game screenshot
+// and saves it in the bot's folder. Returns the path of the saved picture.
+func (a *App) CaptureLoadoutPhoto(options PhotoOptions) (string, error) {
+	destination, err := photoDestination(options.TomlPath, options.AsLogo)
+	if err != nil {
+		return "", err
+	}
+
+	err = a.LaunchPreviewLoadout(options.Preview, flat.ExistingMatchBehaviorRestartIfDifferent)
+	if err != nil {
+		return "", err
+	}
+	if err := a.SetLoadout(options.Preview); err != nil {
+		return "", err
+	}
+
+	a.showcases.Start(a.rlbotAddress(), photoScene(options.Yaw), options.Preview.Team)
+	defer a.showcases.Stop()
+
+	time.Sleep(photoSettleTime)
+
+	dirs := screenshotDirs()
+	if options.ScreenshotDir != "" {
+		dirs = []string{options.ScreenshotDir}
+	}
+
+	// the clocks of the game and the GUI are the same, but file systems round mod times
+	started := time.Now().Add(-time.Second)
+	if err := sendConsoleCommands(a.rlbotAddress(), photoCommands...); err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := sendConsoleCommands(a.rlbotAddress(), photoRestoreCommands...); err != nil {
+			println("WARN: couldn't restore the camera after the photo: " + err.Error())
+		}
+	}()
+
+	deadline := time.Now().Add(photoTimeout)
+	for time.Now().Before(deadline) {
+		if screenshot := newestFile(dirs, started); screenshot != "" {
+			// wait for the game to finish writing it
+			time.Sleep(500 * time.Millisecond)
+			if err := savePng(screenshot, destination); err != nil {
+				return "", err
+			}
+			return destination, nil
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	return "", errors.New("the game didn't save a screenshot in " + strings.Join(dirs, ", "))
+}
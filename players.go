@@ -148,16 +148,50 @@ type BotInfo struct {
 	Config   BotConfig      `json:"config"`
 	Loadout  *LoadoutConfig `json:"loadout,omitempty"`
 	TomlPath string         `json:"tomlPath"`
+	// How the bot will be started, resolved when the bot is discovered
+	// and again when a match starts, since the wine settings may have changed
+	Runner *AgentRunner `json:"runner,omitempty"`
+	// Name of the library loadout used instead of the bot's loadout_file, if any
+	LoadoutOverride string `json:"loadoutOverride"`
+	// Problems found in the loadout by the item database
+	LoadoutProblems []string `json:"loadoutProblems"`
 }
 
-func (botInfo BotInfo) ToPlayerConfig(team uint32) *flat.PlayerConfigurationT {
-	var runCommand string
+func (botInfo BotInfo) RunCommand() string {
+	if botInfo.Runner != nil {
+		return botInfo.Runner.Command
+	}
+
 	if runtime.GOOS == "windows" {
-		runCommand = botInfo.Config.Settings.RunCommand
+		return botInfo.Config.Settings.RunCommand
 	} else if runtime.GOOS == "linux" {
-		runCommand = botInfo.Config.Settings.RunCommandLinux
+		return botInfo.Config.Settings.RunCommandLinux
+	}
+
+	return ""
+}
+
+// withCurrentRunner resolves the runner of info with the current wine settings,
+// instead of trusting the one the frontend got when it listed the bots
+func (a *App) withCurrentRunner(info BotInfo) BotInfo {
+	runner := a.settings.Get().Wine.ResolveRunner(info.Config.Settings)
+	info.Runner = &runner
+	return info
+}
+
+// toPlayer is PlayerJs.ToPlayer, with the runner of bots resolved again
+func (a *App) toPlayer(playerJs PlayerJs) Player {
+	player := playerJs.ToPlayer()
+	if info, ok := player.(BotInfo); ok {
+		return a.withCurrentRunner(info)
 	}
 
+	return player
+}
+
+func (botInfo BotInfo) ToPlayerConfig(team uint32) *flat.PlayerConfigurationT {
+	runCommand := botInfo.RunCommand()
+
 	var loadout *flat.PlayerLoadoutT = nil
 	if botInfo.Loadout != nil {
 		var teamLoadout *TeamLoadoutConfig
@@ -243,6 +277,7 @@ func (a *App) GetBots(paths []string) []BotInfo {
 	}
 
 	infos := []BotInfo{}
+	overrides := a.library.Overrides()
 
 	for _, potentialConfigPath := range potentialConfigs {
 		data, err := os.ReadFile(potentialConfigPath)
@@ -287,11 +322,24 @@ func (a *App) GetBots(paths []string) []BotInfo {
 			}
 		}
 
-		infos = append(infos, BotInfo{
+		runner := a.settings.Get().Wine.ResolveRunner(conf.Settings)
+
+		info := BotInfo{
 			Config:   conf,
 			Loadout:  loadout,
 			TomlPath: potentialConfigPath,
-		})
+			Runner:   &runner,
+		}
+		a.library.applyOverride(&info, overrides)
+
+		if info.Loadout != nil {
+			info.LoadoutProblems = Items().CheckLoadout(*info.Loadout)
+			for _, problem := range info.LoadoutProblems {
+				println("WARN: loadout of " + potentialConfigPath + ": " + problem)
+			}
+		}
+
+		infos = append(infos, info)
 	}
 
 	// sort infos by bot name
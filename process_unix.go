@@ -0,0 +1,18 @@
+//go:build !windows
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// prepareProcess puts the process in its own process group,
+// so that it can be killed together with its children
+func prepareProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func killProcessTree(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGKILL)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const watchInterval = time.Second
+
+func (m *AgentManager) findByPlayer(playerIndex uint32) (*AgentProcess, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, agent := range m.processes {
+		if slices.Contains(agent.PlayerIndices, playerIndex) {
+			return agent, nil
+		}
+	}
+
+	return nil, fmt.Errorf("player %d wasn't launched by the GUI", playerIndex)
+}
+
+// Reload kills the process of agent and starts it again with the same agent id,
+// so that it reconnects to the running match
+func (m *AgentManager) Reload(agent *AgentProcess) error {
+	m.stop(agent)
+
+	m.mu.Lock()
+	done := agent.done
+	m.mu.Unlock()
+
+	if done != nil {
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			return errors.New("timed out waiting for " + agent.Name + " to exit")
+		}
+	}
+
+	m.mu.Lock()
+	agent.stopping = false
+	agent.stopped = nil
+	agent.Restarts = 0
+	m.mu.Unlock()
+
+	return m.start(agent)
+}
+
+// watchIgnored reports if name matches one of the ignore patterns
+func watchIgnored(name string, ignore []string) bool {
+	return slices.ContainsFunc(ignore, func(pattern string) bool {
+		matched, _ := filepath.Match(pattern, name)
+		return matched
+	})
+}
+
+// latestModTime returns the newest modification time of the files in root
+// that auto reload watches according to settings
+func latestModTime(root string, settings SupervisorSettings) time.Time {
+	var latest time.Time
+
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if path != root && watchIgnored(entry.Name(), settings.WatchIgnore) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		if len(settings.WatchExtensions) > 0 && !slices.ContainsFunc(settings.WatchExtensions, func(watched string) bool {
+			return strings.EqualFold(watched, ext)
+		}) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+
+		return nil
+	})
+
+	return latest
+}
+
+// watch reloads agent whenever a file in its root dir changes, until ctx is cancelled
+func (m *AgentManager) watch(ctx context.Context, agent *AgentProcess) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	last := latestModTime(agent.RootDir, m.Settings())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			latest := latestModTime(agent.RootDir, m.Settings())
+			if !latest.After(last) {
+				continue
+			}
+
+			// wait for the editor/compiler to finish writing
+			time.Sleep(watchInterval)
+			if ctx.Err() != nil {
+				return
+			}
+			last = latestModTime(agent.RootDir, m.Settings())
+
+			if err := m.Reload(agent); err != nil {
+				println("WARN: failed to reload " + agent.Name + ": " + err.Error())
+			}
+		}
+	}
+}
+
+func (m *AgentManager) SetAutoReload(agent *AgentProcess, enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cancel, ok := m.watchers[agent.Key]; ok {
+		cancel()
+		delete(m.watchers, agent.Key)
+	}
+
+	agent.AutoReload = enabled
+	if enabled {
+		ctx, cancel := context.WithCancel(context.Background())
+		m.watchers[agent.Key] = cancel
+		go m.watch(ctx, agent)
+	}
+}
+
+// ReloadBot restarts the process controlling the player at playerIndex without restarting the match.
+// Only works for bots that were launched by the GUI.
+func (a *App) ReloadBot(playerIndex uint32) error {
+	agent, err := a.agents.findByPlayer(playerIndex)
+	if err != nil {
+		return err
+	}
+
+	return a.agents.Reload(agent)
+}
+
+// SetBotAutoReload makes the GUI reload the bot at playerIndex whenever its source files change
+func (a *App) SetBotAutoReload(playerIndex uint32, enabled bool) error {
+	agent, err := a.agents.findByPlayer(playerIndex)
+	if err != nil {
+		return err
+	}
+
+	a.agents.SetAutoReload(agent, enabled)
+	return nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+type RHostBot struct {
+	Name   string `json:"name"`
+	Family string `json:"family"`
+	// Name to send to the RocketHost server, the name without notes like "(beta)"
+	Id string `json:"id"`
+}
+
+type RHostBotCatalog struct {
+	Bots []RHostBot `json:"bots"`
+	// Entries of the bot list that couldn't be read
+	Problems  []string  `json:"problems"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	// Where the bot list was fetched from
+	Url string `json:"url"`
+	// The bot list couldn't be fetched, so this is the last one that could
+	Cached bool `json:"cached"`
+}
+
+func newRHostBot(name string, family string) RHostBot {
+	id, _, _ := strings.Cut(name, "(")
+	return RHostBot{Name: name, Family: family, Id: strings.TrimSpace(id)}
+}
+
+// DecodeRHostBots reads the bot list, where every entry is either
+// the name of a bot or a family of bots with its versions.
+// Entries that aren't either are skipped and reported as problems.
+func DecodeRHostBots(data []byte) ([]RHostBot, []string, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, nil, fmt.Errorf("the bot list isn't an array: %w", err)
+	}
+
+	bots := []RHostBot{}
+	problems := []string{}
+
+	for i, entry := range entries {
+		var name string
+		if err := json.Unmarshal(entry, &name); err == nil {
+			if strings.TrimSpace(name) == "" {
+				problems = append(problems, fmt.Sprintf("entry %d: empty bot name", i))
+				continue
+			}
+			bots = append(bots, newRHostBot(name, ""))
+			continue
+		}
+
+		var family struct {
+			Name     string            `json:"name"`
+			Versions []json.RawMessage `json:"versions"`
+		}
+		if err := json.Unmarshal(entry, &family); err != nil {
+			problems = append(problems, fmt.Sprintf("entry %d: neither a bot name nor a family: %s", i, entry))
+			continue
+		}
+		if family.Name == "" {
+			problems = append(problems, fmt.Sprintf("entry %d: family without a \"name\"", i))
+			continue
+		}
+		if len(family.Versions) == 0 {
+			problems = append(problems, fmt.Sprintf("entry %d: family %s without \"versions\"", i, family.Name))
+			continue
+		}
+
+		for j, version := range family.Versions {
+			var versionName string
+			if err := json.Unmarshal(version, &versionName); err != nil || strings.TrimSpace(versionName) == "" {
+				problems = append(problems, fmt.Sprintf("entry %d: version %d of family %s isn't a bot name", i, j, family.Name))
+				continue
+			}
+			bots = append(bots, newRHostBot(versionName, family.Name))
+		}
+	}
+
+	return bots, problems, nil
+}
+
+// Families lists the families of the catalog, bots without one are their own family
+func (catalog RHostBotCatalog) Families() []string {
+	families := []string{}
+	for _, bot := range catalog.Bots {
+		family := bot.Family
+		if family == "" {
+			family = bot.Name
+		}
+		if !slices.Contains(families, family) {
+			families = append(families, family)
+		}
+	}
+
+	return families
+}
+
+// Search returns the bots whose name or family contains query, case insensitive.
+// A non empty family only keeps the bots of that family.
+func (catalog RHostBotCatalog) Search(query string, family string) []RHostBot {
+	query = strings.ToLower(strings.TrimSpace(query))
+	results := []RHostBot{}
+
+	for _, bot := range catalog.Bots {
+		if family != "" && bot.Family != family && (bot.Family != "" || bot.Name != family) {
+			continue
+		}
+
+		if query != "" &&
+			!strings.Contains(strings.ToLower(bot.Name), query) &&
+			!strings.Contains(strings.ToLower(bot.Family), query) {
+			continue
+		}
+
+		results = append(results, bot)
+	}
+
+	return results
+}
+
+// normalizeBotName makes names like "Fridge V5", "fridge_v5" and "FridgeV5" equal
+func normalizeBotName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// MatchLocalBots finds the local bot of every RocketHost bot, by the name
+// or agent id of the local bot. Bots without a local version are left out.
+func MatchLocalBots(bots []RHostBot, local []BotInfo) map[string]BotInfo {
+	byName := map[string]BotInfo{}
+	for _, info := range local {
+		agentId := info.Config.Settings.AgentId
+		_, agentName, _ := strings.Cut(agentId, "/")
+
+		for _, key := range []string{info.Config.Settings.Name, agentId, agentName} {
+			key = normalizeBotName(key)
+			if _, ok := byName[key]; key != "" && !ok {
+				byName[key] = info
+			}
+		}
+	}
+
+	matches := map[string]BotInfo{}
+	for _, bot := range bots {
+		// prefer the exact version over another version of the family
+		for _, key := range []string{bot.Id, bot.Name, bot.Family} {
+			if info, ok := byName[normalizeBotName(key)]; key != "" && ok {
+				matches[bot.Name] = info
+				break
+			}
+		}
+	}
+
+	return matches
+}
+
+// rhostBotCache keeps the last bot list in memory and on disk,
+// to have a list when RocketHost is down. A list of another url isn't used.
+type rhostBotCache struct {
+	mu      sync.Mutex
+	path    string
+	catalog *RHostBotCatalog
+}
+
+func newRHostBotCache(path string) *rhostBotCache {
+	return &rhostBotCache{path: path}
+}
+
+func (cache *rhostBotCache) load() (*RHostBotCatalog, error) {
+	data, err := os.ReadFile(cache.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog RHostBotCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, err
+	}
+
+	return &catalog, nil
+}
+
+func (cache *rhostBotCache) save(catalog *RHostBotCatalog) error {
+	data, err := json.Marshal(catalog)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cache.path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(cache.path, data, 0644)
+}
+
+// Get returns the bot list, fetching it if refresh is set or there is none yet.
+// If fetching fails, the cached list is returned instead.
+// The lock is only held to use the cache, searches don't wait for a slow fetch.
+func (cache *rhostBotCache) Get(ctx context.Context, client *RHostClient, refresh bool) (RHostBotCatalog, error) {
+	cache.mu.Lock()
+	// the bot list url was changed in the settings
+	if cache.catalog != nil && cache.catalog.Url != client.BotListUrl {
+		cache.catalog = nil
+	}
+	cached := cache.catalog
+	cache.mu.Unlock()
+
+	if cached != nil && !refresh {
+		return *cached, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rhostTimeout)
+	defer cancel()
+
+	catalog, err := client.Bots(ctx)
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if err == nil {
+		catalog.Url = client.BotListUrl
+		cache.catalog = &catalog
+		if err := cache.save(&catalog); err != nil {
+			println("WARN: couldn't cache the RocketHost bot list: " + err.Error())
+		}
+		return catalog, nil
+	}
+
+	cached = cache.catalog
+	if cached == nil {
+		cached, _ = cache.load()
+	}
+	if cached == nil || cached.Url != client.BotListUrl {
+		return RHostBotCatalog{}, err
+	}
+
+	println("WARN: using the cached RocketHost bot list: " + err.Error())
+	cached.Cached = true
+	cache.catalog = cached
+	return *cached, nil
+}
+
+func (a *App) GetRHostBots() ([]RHostBot, error) {
+	catalog, err := a.rhostBots.Get(context.Background(), a.rhostClient(), true)
+	return catalog.Bots, err
+}
+
+func (a *App) GetRHostBotCatalog(refresh bool) (RHostBotCatalog, error) {
+	return a.rhostBots.Get(context.Background(), a.rhostClient(), refresh)
+}
+
+func (a *App) SearchRHostBots(query string, family string) ([]RHostBot, error) {
+	catalog, err := a.rhostBots.Get(context.Background(), a.rhostClient(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog.Search(query, family), nil
+}
+
+// MatchRHostBots finds the bots in paths that RocketHost can run, by RocketHost bot name
+func (a *App) MatchRHostBots(paths []string) (map[string]BotInfo, error) {
+	catalog, err := a.rhostBots.Get(context.Background(), a.rhostClient(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	return MatchLocalBots(catalog.Bots, a.GetBots(paths)), nil
+}
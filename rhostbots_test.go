@@ -0,0 +1,74 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDecodeRHostBots(t *testing.T) {
+	data := []byte(`[
+		"Necto",
+		"Seer (beta)",
+		{"name": "Nexto", "versions": ["Nexto", "Nexto (v2)"]},
+		"",
+		42,
+		{"versions": ["Orphan"]},
+		{"name": "Empty", "versions": []},
+		{"name": "Mixed", "versions": ["Mixed v1", 7, " "]}
+	]`)
+
+	bots, problems, err := DecodeRHostBots(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantBots := []RHostBot{
+		{Name: "Necto", Id: "Necto"},
+		{Name: "Seer (beta)", Id: "Seer"},
+		{Name: "Nexto", Family: "Nexto", Id: "Nexto"},
+		{Name: "Nexto (v2)", Family: "Nexto", Id: "Nexto"},
+		{Name: "Mixed v1", Family: "Mixed", Id: "Mixed v1"},
+	}
+	if !slices.Equal(bots, wantBots) {
+		t.Errorf("got bots %+v, want %+v", bots, wantBots)
+	}
+
+	wantProblems := []string{
+		"entry 3: empty bot name",
+		"entry 4: neither a bot name nor a family: 42",
+		`entry 5: family without a "name"`,
+		`entry 6: family Empty without "versions"`,
+		"entry 7: version 1 of family Mixed isn't a bot name",
+		"entry 7: version 2 of family Mixed isn't a bot name",
+	}
+	if !slices.Equal(problems, wantProblems) {
+		t.Errorf("got problems %q, want %q", problems, wantProblems)
+	}
+}
+
+func TestDecodeRHostBotsNotAnArray(t *testing.T) {
+	for _, data := range []string{`{"bots": []}`, `not json`, ``} {
+		if _, _, err := DecodeRHostBots([]byte(data)); err == nil {
+			t.Errorf("%q: decoded a bot list that isn't an array", data)
+		}
+	}
+}
+
+func TestRHostBotCatalogSearch(t *testing.T) {
+	catalog := RHostBotCatalog{Bots: []RHostBot{
+		{Name: "Necto", Id: "Necto"},
+		{Name: "Nexto", Family: "Nexto", Id: "Nexto"},
+		{Name: "Nexto (v2)", Family: "Nexto", Id: "Nexto"},
+	}}
+
+	if families := catalog.Families(); !slices.Equal(families, []string{"Necto", "Nexto"}) {
+		t.Errorf("got families %q", families)
+	}
+
+	if results := catalog.Search(" NEXT ", ""); len(results) != 2 {
+		t.Errorf("got %+v, want both versions of Nexto", results)
+	}
+	if results := catalog.Search("", "Necto"); len(results) != 1 || results[0].Name != "Necto" {
+		t.Errorf("got %+v, want Necto, which is its own family", results)
+	}
+}
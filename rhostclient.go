@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRHostBotListUrl    = "https://rocketleaguemaps.us/api/botList2.json"
+	defaultRHostServerListUrl = "http://serverlist.jetfox.ovh/servers"
+
+	rhostTimeout = 15 * time.Second
+	// creating a match makes the server load the map and start the bots
+	rhostMatchTimeout = 2 * time.Minute
+
+	defaultRHostGameMode = "TAGame.GameInfo_Soccar_TA"
+	rhostNetworkOptions  = "?NumPublicConnections=10?NumOpenPublicConnections=10?Lan?Listen"
+	// max bytes of an error response kept in RHostError
+	rhostErrorBodyLimit = 4096
+)
+
+var defaultRHostMutators = []string{"BotsNone", "PlayerCount8"}
+
+var (
+	// the server answered with a status other than 200
+	ErrRHostStatus = errors.New("unexpected status")
+	// the server answered with something that couldn't be read
+	ErrRHostResponse = errors.New("invalid response")
+)
+
+// RHostError is returned by every request of RHostClient
+type RHostError struct {
+	// What was requested, like "bot list" or "match"
+	Op  string
+	Url string
+	// Status of the response, 0 if there wasn't one
+	StatusCode int
+	// Body of the response if the status wasn't 200
+	Body string
+	Err  error
+}
+
+func (e *RHostError) Error() string {
+	msg := fmt.Sprintf("RocketHost %s request to %s failed: %s", e.Op, e.Url, e.Err)
+	if e.StatusCode != 0 && e.Body != "" {
+		msg += ": " + e.Body
+	}
+
+	return msg
+}
+
+func (e *RHostError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether the request failed because it took too long
+func (e *RHostError) Timeout() bool {
+	var timeout interface{ Timeout() bool }
+	return errors.Is(e.Err, context.DeadlineExceeded) ||
+		(errors.As(e.Err, &timeout) && timeout.Timeout())
+}
+
+// RHostMatchRequest is what a RocketHost server needs to create a match
+type RHostMatchRequest struct {
+	Map string
+	// Like TAGame.GameInfo_Soccar_TA, empty for soccar
+	GameMode string
+	// RocketHost mutator names, nil for the default ones
+	Mutators   []string
+	BlueBots   []string
+	OrangeBots []string
+}
+
+// RHostClient talks to the RocketHost bot and server lists and to RocketHost servers
+type RHostClient struct {
+	BotListUrl    string
+	ServerListUrl string
+	client        *http.Client
+	matchClient   *http.Client
+}
+
+func NewRHostClient(botListUrl string, serverListUrl string) *RHostClient {
+	return &RHostClient{
+		BotListUrl:    botListUrl,
+		ServerListUrl: serverListUrl,
+		client:        &http.Client{Timeout: rhostTimeout},
+		matchClient:   &http.Client{Timeout: rhostMatchTimeout},
+	}
+}
+
+// get requests rawUrl and returns the body of the response if its status is 200
+func (c *RHostClient) get(ctx context.Context, client *http.Client, op string, rawUrl string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return nil, &RHostError{Op: op, Url: rawUrl, Err: err}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &RHostError{Op: op, Url: rawUrl, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, rhostErrorBodyLimit))
+		return nil, &RHostError{
+			Op:         op,
+			Url:        rawUrl,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(body)),
+			Err:        fmt.Errorf("%w %s", ErrRHostStatus, resp.Status),
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &RHostError{Op: op, Url: rawUrl, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	return body, nil
+}
+
+func (c *RHostClient) getJson(ctx context.Context, op string, rawUrl string, v any) error {
+	body, err := c.get(ctx, c.client, op, rawUrl)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return &RHostError{
+			Op:         op,
+			Url:        rawUrl,
+			StatusCode: http.StatusOK,
+			Err:        fmt.Errorf("%w: %w", ErrRHostResponse, err),
+		}
+	}
+
+	return nil
+}
+
+func (c *RHostClient) Bots(ctx context.Context) (RHostBotCatalog, error) {
+	body, err := c.get(ctx, c.client, "bot list", c.BotListUrl)
+	if err != nil {
+		return RHostBotCatalog{}, err
+	}
+
+	bots, problems, err := DecodeRHostBots(body)
+	if err != nil {
+		return RHostBotCatalog{}, &RHostError{
+			Op:         "bot list",
+			Url:        c.BotListUrl,
+			StatusCode: http.StatusOK,
+			Err:        fmt.Errorf("%w: %w", ErrRHostResponse, err),
+		}
+	}
+
+	for _, problem := range problems {
+		println("WARN: skipped RocketHost bot list " + problem)
+	}
+
+	return RHostBotCatalog{Bots: bots, Problems: problems, FetchedAt: time.Now()}, nil
+}
+
+func (c *RHostClient) Servers(ctx context.Context) ([]RHostServer, error) {
+	var servers []RHostServer
+	if err := c.getJson(ctx, "server list", c.ServerListUrl, &servers); err != nil {
+		return nil, err
+	}
+
+	return servers, nil
+}
+
+// MatchUrl returns the url that makes the RocketHost server at server create a match.
+// server is a host:port, or a url for servers that aren't plain http.
+func MatchUrl(server string, request RHostMatchRequest) (string, error) {
+	if !strings.Contains(server, "://") {
+		server = "http://" + server
+	}
+
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", errors.New("no host in RocketHost server " + server)
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	gameMode := request.GameMode
+	if gameMode == "" {
+		gameMode = defaultRHostGameMode
+	}
+
+	mutators := request.Mutators
+	if mutators == nil {
+		mutators = defaultRHostMutators
+	}
+
+	// same order and encoding as the urls RocketHost servers were written for,
+	// url.Values would sort the keys and escape the ?s of the network options
+	query := [][2]string{
+		{"mapName", request.Map},
+		{"gameMode", gameMode},
+		{"mutators", strings.Join(mutators, ",")},
+		{"networkOptions", rhostNetworkOptions},
+		{"rlbot", "yes"},
+		{"blueBots", strings.Join(request.BlueBots, ",")},
+		{"orangeBots", strings.Join(request.OrangeBots, ",")},
+	}
+
+	params := make([]string, len(query))
+	for i, param := range query {
+		params[i] = param[0] + "=" + rhostQueryEscape(param[1])
+	}
+	u.RawQuery = strings.Join(params, "&")
+
+	return u.String(), nil
+}
+
+// characters that are allowed in a query and that RocketHost servers expect unescaped
+var rhostQueryUnescaper = strings.NewReplacer("%3F", "?", "%2C", ",", "%3D", "=", "%3A", ":", "+", "%20")
+
+// rhostQueryEscape escapes value for a query, with spaces as %20 instead of +
+func rhostQueryEscape(value string) string {
+	return rhostQueryUnescaper.Replace(url.QueryEscape(value))
+}
+
+// CreateMatch asks the RocketHost server at server to create a match
+// and returns the address the game has to join
+func (c *RHostClient) CreateMatch(ctx context.Context, server string, request RHostMatchRequest) (string, error) {
+	matchUrl, err := MatchUrl(server, request)
+	if err != nil {
+		return "", &RHostError{Op: "match", Url: server, Err: err}
+	}
+
+	body, err := c.get(ctx, c.matchClient, "match", matchUrl)
+	if err != nil {
+		return "", err
+	}
+
+	address := strings.TrimSpace(string(body))
+	if address == "" || strings.ContainsAny(address, " \t\r\n") {
+		return "", &RHostError{
+			Op:         "match",
+			Url:        matchUrl,
+			StatusCode: http.StatusOK,
+			Err:        fmt.Errorf("%w: %q isn't an address", ErrRHostResponse, address),
+		}
+	}
+
+	return address, nil
+}
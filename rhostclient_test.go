@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRHostClient(t *testing.T, handler http.HandlerFunc) (*RHostClient, *httptest.Server) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewRHostClient(server.URL+"/bots", server.URL+"/servers"), server
+}
+
+func TestRHostServers(t *testing.T) {
+	client, _ := newTestRHostClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/servers" {
+			t.Errorf("requested %s, want /servers", r.URL.Path)
+		}
+		w.Write([]byte(`[{"ip":"1.2.3.4","port":"7777","location":"EU","domain":"eu.example"}]`))
+	})
+
+	servers, err := client.Servers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := RHostServer{Ip: "1.2.3.4", Port: "7777", Location: "EU", Domain: "eu.example"}
+	if len(servers) != 1 || servers[0] != want {
+		t.Fatalf("got %+v, want [%+v]", servers, want)
+	}
+}
+
+func TestRHostStatusError(t *testing.T) {
+	client, server := newTestRHostClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "server list is down", http.StatusServiceUnavailable)
+	})
+
+	_, err := client.Servers(context.Background())
+
+	var rhostErr *RHostError
+	if !errors.As(err, &rhostErr) {
+		t.Fatalf("got %v, want an RHostError", err)
+	}
+	if !errors.Is(err, ErrRHostStatus) {
+		t.Errorf("got %v, want ErrRHostStatus", err)
+	}
+	if rhostErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want 503", rhostErr.StatusCode)
+	}
+	if rhostErr.Body != "server list is down" {
+		t.Errorf("got body %q", rhostErr.Body)
+	}
+	if rhostErr.Url != server.URL+"/servers" || rhostErr.Op != "server list" {
+		t.Errorf("got op %q and url %q", rhostErr.Op, rhostErr.Url)
+	}
+	if rhostErr.Timeout() {
+		t.Error("a status error isn't a timeout")
+	}
+}
+
+func TestRHostBadJson(t *testing.T) {
+	client, _ := newTestRHostClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html>not json</html>`))
+	})
+
+	_, err := client.Servers(context.Background())
+	if !errors.Is(err, ErrRHostResponse) {
+		t.Fatalf("got %v, want ErrRHostResponse", err)
+	}
+
+	_, err = client.Bots(context.Background())
+	if !errors.Is(err, ErrRHostResponse) {
+		t.Fatalf("got %v, want ErrRHostResponse", err)
+	}
+}
+
+func TestRHostTimeout(t *testing.T) {
+	release := make(chan struct{})
+	client, _ := newTestRHostClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer close(release)
+	client.client.Timeout = 50 * time.Millisecond
+
+	_, err := client.Servers(context.Background())
+
+	var rhostErr *RHostError
+	if !errors.As(err, &rhostErr) {
+		t.Fatalf("got %v, want an RHostError", err)
+	}
+	if !rhostErr.Timeout() {
+		t.Errorf("got %v, want a timeout", err)
+	}
+}
+
+func TestRHostContextTimeout(t *testing.T) {
+	release := make(chan struct{})
+	client, _ := newTestRHostClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.CreateMatch(ctx, strings.TrimPrefix(client.ServerListUrl, "http://"), RHostMatchRequest{})
+
+	var rhostErr *RHostError
+	if !errors.As(err, &rhostErr) || !rhostErr.Timeout() {
+		t.Fatalf("got %v, want a timeout", err)
+	}
+}
+
+func TestMatchUrl(t *testing.T) {
+	url, err := MatchUrl("1.2.3.4:7777", RHostMatchRequest{
+		Map:        "ARC_Darc_P",
+		BlueBots:   []string{"Nexto", "Necto"},
+		OrangeBots: []string{"Element"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// what RocketHost servers were sent before RHostClient existed
+	want := "http://1.2.3.4:7777/?mapName=ARC_Darc_P&gameMode=TAGame.GameInfo_Soccar_TA" +
+		"&mutators=BotsNone,PlayerCount8" +
+		"&networkOptions=?NumPublicConnections=10?NumOpenPublicConnections=10?Lan?Listen" +
+		"&rlbot=yes&blueBots=Nexto,Necto&orangeBots=Element"
+	if url != want {
+		t.Errorf("got  %s\nwant %s", url, want)
+	}
+}
+
+func TestMatchUrlEscapes(t *testing.T) {
+	url, err := MatchUrl("http://host:1/base", RHostMatchRequest{
+		Map:      "a map&more",
+		Mutators: []string{"Bots+None"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(url, "http://host:1/base?mapName=a%20map%26more&") {
+		t.Errorf("map isn't escaped: %s", url)
+	}
+	if !strings.Contains(url, "&mutators=Bots%2BNone&") {
+		t.Errorf("mutators aren't escaped: %s", url)
+	}
+}
+
+func TestCreateMatch(t *testing.T) {
+	var query map[string][]string
+	var rawQuery string
+	client, server := newTestRHostClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		rawQuery = r.URL.RawQuery
+		w.Write([]byte("5.6.7.8:7779\n"))
+	})
+
+	address, err := client.CreateMatch(context.Background(), strings.TrimPrefix(server.URL, "http://"), RHostMatchRequest{
+		Map:        "ARC_Darc_P",
+		GameMode:   "TAGame.GameInfo_Basketball_TA",
+		Mutators:   []string{"BotsNone", "PlayerCount4"},
+		BlueBots:   []string{"Nexto"},
+		OrangeBots: []string{"Fridge V5"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != "5.6.7.8:7779" {
+		t.Errorf("got address %q", address)
+	}
+
+	// the server gets the values back as they were, with the ?s of the network options raw
+	want := map[string]string{
+		"mapName":        "ARC_Darc_P",
+		"gameMode":       "TAGame.GameInfo_Basketball_TA",
+		"mutators":       "BotsNone,PlayerCount4",
+		"networkOptions": rhostNetworkOptions,
+		"rlbot":          "yes",
+		"blueBots":       "Nexto",
+		"orangeBots":     "Fridge V5",
+	}
+	for key, value := range want {
+		if got := query[key]; len(got) != 1 || got[0] != value {
+			t.Errorf("%s: got %q, want %q", key, got, value)
+		}
+	}
+	if !strings.Contains(rawQuery, "networkOptions="+rhostNetworkOptions+"&") {
+		t.Errorf("network options are escaped: %s", rawQuery)
+	}
+}
+
+func TestCreateMatchBadAddress(t *testing.T) {
+	client, server := newTestRHostClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("no servers left\n"))
+	})
+
+	_, err := client.CreateMatch(context.Background(), server.URL, RHostMatchRequest{})
+	if !errors.Is(err, ErrRHostResponse) {
+		t.Fatalf("got %v, want ErrRHostResponse", err)
+	}
+}
+
+func TestFastestServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	// a port that nothing listens on
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, closedPort, _ := net.SplitHostPort(closed.Addr().String())
+	closed.Close()
+
+	client, _ := newTestRHostClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"ip":"127.0.0.1","port":"` + closedPort + `"},{"ip":"127.0.0.1","port":"` + port + `"}]`))
+	})
+
+	address, err := client.FastestServer(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != "127.0.0.1:"+port {
+		t.Errorf("got %s, want the listening server 127.0.0.1:%s", address, port)
+	}
+}
+
+func TestFastestServerNoneReachable(t *testing.T) {
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, closedPort, _ := net.SplitHostPort(closed.Addr().String())
+	closed.Close()
+
+	client, _ := newTestRHostClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"ip":"127.0.0.1","port":"` + closedPort + `"}]`))
+	})
+
+	if _, err := client.FastestServer(context.Background()); err == nil {
+		t.Fatal("got no error without a reachable server")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// RHostMatchSettings.Server that picks the server with the lowest latency
+	RHostAutoServer = "auto"
+
+	rhostProbeTimeout = 2 * time.Second
+	// the fastest of these connects is the latency, the first one also resolves dns etc
+	rhostProbeAttempts = 3
+)
+
+type RHostServerLatency struct {
+	Server    RHostServer `json:"server"`
+	Address   string      `json:"address"`
+	Reachable bool        `json:"reachable"`
+	// Time to open a TCP connection to the server, 0 if it isn't reachable
+	LatencyMs float64 `json:"latencyMs"`
+	// Why the server isn't reachable
+	Error string `json:"error"`
+}
+
+func (server RHostServer) Address() string {
+	return net.JoinHostPort(server.Ip, server.Port)
+}
+
+// probeLatency times TCP connects to address and returns the fastest one
+func probeLatency(ctx context.Context, address string) (time.Duration, error) {
+	var dialer net.Dialer
+	best := time.Duration(-1)
+	var lastErr error
+
+	for range rhostProbeAttempts {
+		attemptCtx, cancel := context.WithTimeout(ctx, rhostProbeTimeout)
+		start := time.Now()
+		conn, err := dialer.DialContext(attemptCtx, "tcp", address)
+		elapsed := time.Since(start)
+		cancel()
+
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conn.Close()
+
+		if best < 0 || elapsed < best {
+			best = elapsed
+		}
+	}
+
+	if best < 0 {
+		return 0, lastErr
+	}
+
+	return best, nil
+}
+
+// ProbeRHostServers measures the latency to every server at the same time.
+// The result is sorted by latency, with the unreachable servers last.
+func ProbeRHostServers(ctx context.Context, servers []RHostServer) []RHostServerLatency {
+	results := make([]RHostServerLatency, len(servers))
+
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := RHostServerLatency{Server: server, Address: server.Address()}
+			latency, err := probeLatency(ctx, result.Address)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Reachable = true
+				result.LatencyMs = float64(latency.Microseconds()) / 1000
+			}
+
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	slices.SortStableFunc(results, func(a, b RHostServerLatency) int {
+		if a.Reachable != b.Reachable {
+			if a.Reachable {
+				return -1
+			}
+			return 1
+		}
+
+		if a.LatencyMs < b.LatencyMs {
+			return -1
+		} else if a.LatencyMs > b.LatencyMs {
+			return 1
+		}
+		return 0
+	})
+
+	return results
+}
+
+// FastestServer returns the address of the reachable server with the lowest latency
+func (c *RHostClient) FastestServer(ctx context.Context) (string, error) {
+	servers, err := c.Servers(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	results := ProbeRHostServers(ctx, servers)
+	if len(results) == 0 || !results[0].Reachable {
+		return "", errors.New("none of the RocketHost servers are reachable")
+	}
+
+	return results[0].Address, nil
+}
+
+func (a *App) ProbeRHostServers() ([]RHostServerLatency, error) {
+	servers, err := a.rhostClient().Servers(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return ProbeRHostServers(context.Background(), servers), nil
+}
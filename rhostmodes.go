@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/RLBot/go-interface/flat"
+)
+
+// game modes RocketHost servers can create, with the GameInfo class they ask the game for
+var rhostGameModes = map[string]string{
+	"Soccar":     defaultRHostGameMode,
+	"Hoops":      "TAGame.GameInfo_Basketball_TA",
+	"Dropshot":   "TAGame.GameInfo_Breakout_TA",
+	"Snowday":    "TAGame.GameInfo_Hockey_TA",
+	"Rumble":     "TAGame.GameInfo_Items_TA",
+	"Heatseeker": "TAGame.GameInfo_GodBall_TA",
+}
+
+// RocketHost names of the mutator options RocketHost servers support,
+// by the json name of the mutator in flat.MutatorSettingsT and the index of the option.
+// The first option of every mutator is the default and doesn't need a name.
+var rhostMutators = map[string]map[int]string{
+	"match_length":   {1: "10Minutes", 2: "20Minutes", 3: "UnlimitedTime"},
+	"max_score":      {1: "Max1", 2: "Max3", 3: "Max5"},
+	"game_speed":     {1: "SloMoGameSpeed", 2: "SloMoDistanceBall"},
+	"ball_max_speed": {1: "SlowBall", 2: "FastBall", 3: "SuperFastBall"},
+	"ball_type":      {1: "Ball_CubeBall", 2: "Ball_Puck", 3: "Ball_BasketBall", 4: "Ball_BeachBall"},
+	"boost_amount":   {1: "UnlimitedBooster", 2: "SlowBooster", 3: "FastBooster", 4: "NoBooster"},
+	"gravity":        {1: "LowGravity", 2: "HighGravity", 3: "SuperGravity", 4: "ReverseGravity"},
+	"demolish":       {1: "NoDemolish", 3: "DemolishAll"},
+	"respawn_time":   {1: "TwoSecondsRespawn", 2: "OnceSecondRespawn", 3: "DisableGoalDelay"},
+}
+
+// RHostModes is what RocketHost servers support, for the GUI to only offer those
+type RHostModes struct {
+	GameModes []string `json:"gameModes"`
+	// Supported options of every mutator, by json name
+	Mutators map[string][]int `json:"mutators"`
+}
+
+// rhostMutatorNames returns the RocketHost names of the non default options in mutators,
+// or an error if RocketHost servers don't support one of them
+func rhostMutatorNames(mutators flat.MutatorSettingsT) ([]string, error) {
+	data, err := json.Marshal(mutators)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]int
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	names := slices.Clone(defaultRHostMutators)
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		value := values[key]
+		if value == 0 {
+			continue
+		}
+
+		name, ok := rhostMutators[key][value]
+		if !ok {
+			return nil, fmt.Errorf("RocketHost servers don't support option %d of the %s mutator", value, key)
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// request checks settings against what RocketHost servers support and returns
+// the request for the server and the game mode of the local match
+func (settings RHostMatchSettings) request() (RHostMatchRequest, flat.GameMode, error) {
+	modeName := settings.GameMode
+	if modeName == "" {
+		modeName = "Soccar"
+	}
+
+	gameInfo, ok := rhostGameModes[modeName]
+	if !ok {
+		return RHostMatchRequest{}, 0, fmt.Errorf("RocketHost servers don't support the %s game mode", modeName)
+	}
+
+	mutators, err := rhostMutatorNames(settings.MutatorSettings)
+	if err != nil {
+		return RHostMatchRequest{}, 0, err
+	}
+
+	return RHostMatchRequest{
+		Map:        settings.Map,
+		GameMode:   gameInfo,
+		Mutators:   mutators,
+		BlueBots:   settings.BlueBots,
+		OrangeBots: settings.OrangeBots,
+	}, gameModes[modeName], nil
+}
+
+func (a *App) GetRHostModes() RHostModes {
+	modes := RHostModes{
+		GameModes: slices.Sorted(maps.Keys(rhostGameModes)),
+		Mutators:  map[string][]int{},
+	}
+
+	for key, options := range rhostMutators {
+		modes.Mutators[key] = append([]int{0}, slices.Sorted(maps.Keys(options))...)
+	}
+
+	return modes
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"os"
+)
+
+// environment variables that override the saved RocketHost settings
+const (
+	rhostBotListUrlEnv    = "RHOST_BOT_LIST_URL"
+	rhostServerListUrlEnv = "RHOST_SERVER_LIST_URL"
+)
+
+type RHostSettings struct {
+	// Url of the RocketHost bot list, the public one if empty
+	BotListUrl string `toml:"bot_list_url" json:"botListUrl"`
+	// Url of the RocketHost server list, the public one if empty
+	ServerListUrl string `toml:"server_list_url" json:"serverListUrl"`
+}
+
+func validateEndpoint(name string, endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return errors.New("invalid " + name + ": " + err.Error())
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New(name + " must be an http or https url")
+	}
+	if u.Host == "" {
+		return errors.New(name + " has no host")
+	}
+
+	return nil
+}
+
+func (s RHostSettings) Validate() error {
+	if err := validateEndpoint("bot list url", s.BotListUrl); err != nil {
+		return err
+	}
+
+	return validateEndpoint("server list url", s.ServerListUrl)
+}
+
+// Endpoints returns the urls to use, with the environment overriding
+// the saved settings and the public urls as the fallback
+func (s RHostSettings) Endpoints() (botListUrl string, serverListUrl string) {
+	botListUrl = os.Getenv(rhostBotListUrlEnv)
+	if botListUrl == "" {
+		botListUrl = s.BotListUrl
+	}
+	if botListUrl == "" {
+		botListUrl = defaultRHostBotListUrl
+	}
+
+	serverListUrl = os.Getenv(rhostServerListUrlEnv)
+	if serverListUrl == "" {
+		serverListUrl = s.ServerListUrl
+	}
+	if serverListUrl == "" {
+		serverListUrl = defaultRHostServerListUrl
+	}
+
+	return botListUrl, serverListUrl
+}
+
+func (a *App) GetRHostSettings() RHostSettings {
+	return a.settings.Get().RocketHost
+}
+
+// GetRHostOverrides lists the RocketHost settings that the environment overrides,
+// by json name of the setting
+func (a *App) GetRHostOverrides() map[string]string {
+	overrides := map[string]string{}
+	if value := os.Getenv(rhostBotListUrlEnv); value != "" {
+		overrides["botListUrl"] = rhostBotListUrlEnv + "=" + value
+	}
+	if value := os.Getenv(rhostServerListUrlEnv); value != "" {
+		overrides["serverListUrl"] = rhostServerListUrlEnv + "=" + value
+	}
+
+	return overrides
+}
+
+func (a *App) SetRHostSettings(settings RHostSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	err := a.settings.Update(func(s *Settings) {
+		s.RocketHost = settings
+	})
+	if err != nil {
+		return err
+	}
+
+	a.applySettings(a.settings.Get())
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	rlbot "github.com/RLBot/go-interface"
+	"github.com/RLBot/go-interface/flat"
+)
+
+// time for the game to load the map of the RocketHost match and join it
+const rhostJoinTimeout = 45 * time.Second
+
+type RHostState string
+
+const (
+	RHostRequesting  RHostState = "requesting"
+	RHostServerReady RHostState = "server-ready"
+	RHostJoining     RHostState = "joining"
+	RHostInMatch     RHostState = "in-match"
+	RHostFailed      RHostState = "failed"
+)
+
+// RHostStatus is sent to the frontend as the "rhost-status" event
+// whenever StartRHostMatch moves on to another state
+type RHostStatus struct {
+	State  RHostState `json:"state"`
+	Server string     `json:"server"`
+	// Address of the match on the server, once it's ready
+	Address string `json:"address"`
+	// Join attempt, starting at 1
+	Attempt  int    `json:"attempt"`
+	Attempts int    `json:"attempts"`
+	Message  string `json:"message"`
+}
+
+func emitRHostStatus(status RHostStatus) {
+	emitEvent("rhost-status", status)
+}
+
+// drainMessages reads packetChan until ReadAllMessages stops sending to it,
+// or gives up if it already stopped
+func drainMessages(packetChan chan any) {
+	for {
+		select {
+		case item := <-packetChan:
+			switch item.(type) {
+			case error, *flat.DisconnectSignalT:
+				return
+			}
+		case <-time.After(5 * time.Second):
+			return
+		}
+	}
+}
+
+func waitForFieldInfo(conn *rlbot.RLBotConnection, packetChan chan any) error {
+	for {
+		item := <-packetChan
+		if err, ok := item.(error); ok {
+			return errors.New("Error reading packet from rlbotserver: " + err.Error())
+		}
+
+		switch item.(type) {
+		case *flat.FieldInfoT:
+			conn.SendPacket(&flat.InitCompleteT{})
+			return nil
+		case *flat.DisconnectSignalT:
+			return errors.New("RLBotServer closed the connection while loading the match")
+		}
+	}
+}
+
+func renderRHostLoading(conn *rlbot.RLBotConnection) {
+	err := conn.SendPacket(&flat.RenderGroupT{
+		RenderMessages: []*flat.RenderMessageT{
+			{
+				Variety: &flat.RenderTypeT{
+					Type: flat.RenderTypeString2D,
+					Value: &flat.String2DT{
+						Text:  "Loading RocketHost game...",
+						X:     0.5,
+						Y:     0.5,
+						Scale: 1.5,
+						Foreground: &flat.ColorT{
+							R: 255,
+							G: 255,
+							B: 255,
+							A: 255,
+						},
+						Background: &flat.ColorT{
+							R: 0,
+							G: 0,
+							B: 0,
+							A: 255,
+						},
+						HAlign: flat.TextHAlignCenter,
+						VAlign: flat.TextVAlignCenter,
+					},
+				},
+			},
+		},
+		Id: 0,
+	})
+	if err != nil {
+		println("WARN: couldn't render loading message")
+	}
+}
+
+// waitForRHostServer shows a loading message in game until the RocketHost server answers
+func waitForRHostServer(conn *rlbot.RLBotConnection, packetChan chan any, respRHostChan chan Result) (Result, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	renderRHostLoading(conn)
+	for {
+		select {
+		case result := <-respRHostChan:
+			return result, nil
+		case item := <-packetChan:
+			if err, ok := item.(error); ok {
+				return Result{}, errors.New("Error reading packet from rlbotserver: " + err.Error())
+			}
+			if _, ok := item.(*flat.DisconnectSignalT); ok {
+				return Result{}, errors.New("RLBotServer closed the connection while waiting for RocketHost")
+			}
+		case <-ticker.C:
+			renderRHostLoading(conn)
+		}
+	}
+}
+
+// waitForRHostJoin reports whether the game joined the RocketHost match before the timeout.
+// The local match has no players, so players in the game packet mean the join worked.
+func waitForRHostJoin(packetChan chan any, timeout time.Duration) (bool, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case item := <-packetChan:
+			if err, ok := item.(error); ok {
+				return false, errors.New("Error reading packet from rlbotserver: " + err.Error())
+			}
+
+			switch packet := item.(type) {
+			case *flat.GamePacketT:
+				if len(packet.Players) > 0 && packet.MatchInfo != nil &&
+					packet.MatchInfo.MatchPhase != flat.MatchPhaseInactive &&
+					packet.MatchInfo.MatchPhase != flat.MatchPhaseEnded {
+					return true, nil
+				}
+			case *flat.DisconnectSignalT:
+				return false, errors.New("RLBotServer closed the connection while joining")
+			}
+		case <-timer.C:
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,519 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	rlbot "github.com/RLBot/go-interface"
+	"github.com/RLBot/go-interface/flat"
+)
+
+const (
+	rlbotServerRepo = "RLBot/core"
+
+	// time for a started RLBotServer to accept connections
+	serverStartTimeout = 20 * time.Second
+	// time for RLBotServer to exit after being asked to, before it's killed
+	serverStopTimeout = 5 * time.Second
+	serverDialTimeout = 500 * time.Millisecond
+)
+
+var errServerNotFound = errors.New("RLBotServer isn't installed")
+
+type ServerProcessSettings struct {
+	// RLBotServer binary to run, empty to use the downloaded one
+	Path string `toml:"path" json:"path"`
+	// Start RLBotServer when a match is started and the local server isn't running
+	AutoStart bool `toml:"auto_start" json:"autoStart"`
+	// Shut RLBotServer down with the GUI, if the GUI started it
+	StopWithGui bool `toml:"stop_with_gui" json:"stopWithGui"`
+}
+
+func DefaultServerProcessSettings() ServerProcessSettings {
+	return ServerProcessSettings{AutoStart: true, StopWithGui: true}
+}
+
+// ServerProcessStatus is sent to the frontend as the "server-status" event
+// whenever the RLBotServer run by the GUI starts or exits
+type ServerProcessStatus struct {
+	// The GUI is running RLBotServer
+	Running bool   `json:"running"`
+	Pid     int    `json:"pid"`
+	Path    string `json:"path"`
+	// Release tag of the binary, empty if it wasn't downloaded by the GUI
+	Version string `json:"version"`
+	Address string `json:"address"`
+	// RLBotServer accepts connections
+	Healthy  bool   `json:"healthy"`
+	ExitCode int    `json:"exitCode"`
+	LogPath  string `json:"logPath"`
+	// Why RLBotServer couldn't be started or exited
+	Error string `json:"error"`
+}
+
+// ServerManager runs an RLBotServer for the GUI, when there isn't one already
+type ServerManager struct {
+	mu sync.Mutex
+	// held while starting the server, so that matches started at the same time
+	// don't both start one on the same port
+	startMu sync.Mutex
+	// where the downloaded RLBotServer goes
+	dir     string
+	logPath string
+	logs    *ServerLog
+	cmd     *exec.Cmd
+	// closed once the process exited
+	done     chan struct{}
+	stopping bool
+	status   ServerProcessStatus
+}
+
+func NewServerManager(dir string, logPath string) *ServerManager {
+	return &ServerManager{
+		dir:     dir,
+		logPath: logPath,
+		logs:    NewServerLog(),
+		status:  ServerProcessStatus{LogPath: logPath},
+	}
+}
+
+func serverBinaryName() string {
+	if runtime.GOOS == "windows" {
+		return "RLBotServer.exe"
+	}
+
+	return "RLBotServer"
+}
+
+func (m *ServerManager) downloadedPath() string {
+	return filepath.Join(m.dir, serverBinaryName())
+}
+
+func (m *ServerManager) versionPath() string {
+	return filepath.Join(m.dir, "version.txt")
+}
+
+// Locate finds the RLBotServer to run: path if set, else the downloaded one, else one in PATH.
+// Returns the version of the binary if the GUI downloaded it.
+func (m *ServerManager) Locate(path string) (string, string, error) {
+	if path != "" {
+		if !fileExists(path) {
+			return "", "", errors.New("RLBotServer not found at " + path)
+		}
+		return path, "", nil
+	}
+
+	if fileExists(m.downloadedPath()) {
+		version, _ := os.ReadFile(m.versionPath())
+		return m.downloadedPath(), strings.TrimSpace(string(version)), nil
+	}
+
+	if path, err := exec.LookPath(serverBinaryName()); err == nil {
+		return path, "", nil
+	}
+
+	return "", "", errServerNotFound
+}
+
+func downloadFile(url string, dest string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading %s failed: %s", url, resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	// don't leave a broken binary if the download gets cut off
+	tmp := dest + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, dest)
+}
+
+func isLocalAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func serverListening(address string) bool {
+	conn, err := net.DialTimeout("tcp", address, serverDialTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+
+	return true
+}
+
+func (m *ServerManager) setStatus(update func(*ServerProcessStatus)) {
+	m.mu.Lock()
+	update(&m.status)
+	status := m.status
+	m.mu.Unlock()
+
+	emitEvent("server-status", status)
+}
+
+func (m *ServerManager) pipeOutput(log *RotatingLog, stream string, reader io.Reader, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		m.addLog(log, scanner.Text(), stream)
+	}
+}
+
+// addLog parses a line of output, keeps it in the log file and sends it to the frontend
+func (m *ServerManager) addLog(log *RotatingLog, line string, stream string) {
+	entry := m.logs.Add(line, stream)
+	fmt.Fprintln(log, entry.String())
+	emitEvent("server-log", entry)
+}
+
+func (m *ServerManager) logGui(log *RotatingLog, format string, args ...any) {
+	m.addLog(log, fmt.Sprintf(format, args...), "gui")
+}
+
+// Start runs the RLBotServer at path for address, if the GUI isn't running one already
+func (m *ServerManager) Start(path string, version string, address string) error {
+	m.mu.Lock()
+	running := m.cmd != nil
+	runningAddress := m.status.Address
+	m.mu.Unlock()
+	if running {
+		if runningAddress != address {
+			return errors.New("already running RLBotServer at " + runningAddress)
+		}
+		return nil
+	}
+
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	// RLBotServer listens on the port given as its argument, 23234 without one
+	args := []string{}
+	if _, defaultPort, _ := net.SplitHostPort(defaultRLBotAddress); port != defaultPort {
+		args = append(args, port)
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Dir = filepath.Dir(path)
+	prepareProcess(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	log, err := OpenRotatingLog(m.logPath, defaultLogSize, defaultLogFiles)
+	if err != nil {
+		return err
+	}
+
+	m.logGui(log, "starting %s on port %s", path, port)
+
+	if err := cmd.Start(); err != nil {
+		m.logGui(log, "failed to start: %s", err)
+		log.Close()
+		return err
+	}
+
+	done := make(chan struct{})
+
+	m.mu.Lock()
+	m.cmd = cmd
+	m.done = done
+	m.stopping = false
+	m.mu.Unlock()
+
+	m.setStatus(func(status *ServerProcessStatus) {
+		*status = ServerProcessStatus{
+			Running: true,
+			Pid:     cmd.Process.Pid,
+			Path:    path,
+			Version: version,
+			Address: address,
+			LogPath: m.logPath,
+		}
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go m.pipeOutput(log, "stdout", stdout, &wg)
+	go m.pipeOutput(log, "stderr", stderr, &wg)
+
+	go func() {
+		// all output has to be read before calling Wait
+		wg.Wait()
+		err := cmd.Wait()
+
+		m.mu.Lock()
+		stopping := m.stopping
+		m.cmd = nil
+		m.mu.Unlock()
+
+		exitCode := cmd.ProcessState.ExitCode()
+		m.logGui(log, "exited with code %d", exitCode)
+		log.Close()
+
+		m.setStatus(func(status *ServerProcessStatus) {
+			status.Running = false
+			status.Healthy = false
+			status.ExitCode = exitCode
+			if err != nil && !stopping {
+				status.Error = "RLBotServer exited: " + err.Error()
+			}
+		})
+		close(done)
+	}()
+
+	return nil
+}
+
+// WaitHealthy waits until the started RLBotServer accepts connections
+func (m *ServerManager) WaitHealthy(timeout time.Duration) error {
+	m.mu.Lock()
+	done := m.done
+	address := m.status.Address
+	m.mu.Unlock()
+
+	if done == nil {
+		return errors.New("RLBotServer isn't running")
+	}
+
+	deadline := time.Now().Add(timeout)
+	for !serverListening(address) {
+		select {
+		case <-done:
+			return fmt.Errorf("RLBotServer exited while starting, see %s", m.logPath)
+		case <-time.After(serverDialTimeout):
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("RLBotServer didn't accept connections at %s after %s", address, timeout)
+		}
+	}
+
+	m.setStatus(func(status *ServerProcessStatus) {
+		status.Healthy = true
+	})
+	return nil
+}
+
+// Running reports whether the GUI is running RLBotServer
+func (m *ServerManager) Running() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.cmd != nil
+}
+
+// RunningAt reports whether the GUI is running the RLBotServer at address
+func (m *ServerManager) RunningAt(address string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.cmd != nil && m.status.Address == address
+}
+
+// Status returns the status of the RLBotServer run by the GUI, checking if it accepts connections
+func (m *ServerManager) Status() ServerProcessStatus {
+	m.mu.Lock()
+	status := m.status
+	m.mu.Unlock()
+
+	if status.Running {
+		status.Healthy = serverListening(status.Address)
+	}
+
+	return status
+}
+
+// Stop asks the RLBotServer run by the GUI to shut down, killing it if it doesn't
+func (m *ServerManager) Stop() error {
+	m.mu.Lock()
+	cmd := m.cmd
+	done := m.done
+	address := m.status.Address
+	m.stopping = true
+	m.mu.Unlock()
+
+	if cmd == nil {
+		return nil
+	}
+
+	if conn, err := rlbot.Connect(address); err == nil {
+		conn.SendPacket(&flat.StopCommandT{ShutdownServer: true})
+		conn.SendPacket(&flat.DisconnectSignalT{})
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(serverStopTimeout):
+	}
+
+	if err := killProcessTree(cmd.Process.Pid); err != nil {
+		return err
+	}
+
+	<-done
+	return nil
+}
+
+// DownloadServer downloads the latest RLBotServer release, returning its tag
+func (a *App) DownloadServer() (string, error) {
+	latestRelease, err := a.GetLatestReleaseData(rlbotServerRepo)
+	if err != nil {
+		return "", err
+	}
+
+	var downloadUrl string
+	for _, asset := range latestRelease.Assets {
+		if asset.Name == serverBinaryName() {
+			downloadUrl = asset.BrowserDownloadURL
+			break
+		}
+	}
+	if downloadUrl == "" {
+		return "", errors.New("the latest RLBotServer release has no " + serverBinaryName())
+	}
+
+	if a.server.Running() && a.server.Status().Path == a.server.downloadedPath() {
+		return "", errors.New("stop RLBotServer before updating it")
+	}
+
+	if err := downloadFile(downloadUrl, a.server.downloadedPath()); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(a.server.versionPath(), []byte(latestRelease.TagName), 0644); err != nil {
+		return "", err
+	}
+
+	return latestRelease.TagName, nil
+}
+
+// startServer runs RLBotServer for the active server profile, downloading it if needed
+func (a *App) startServer() error {
+	a.server.startMu.Lock()
+	defer a.server.startMu.Unlock()
+
+	settings := a.settings.Get()
+	address := settings.ServerAddress()
+	if !isLocalAddress(address) {
+		return errors.New("the GUI can only run RLBotServer for profiles on this computer, not " + address)
+	}
+
+	// started while waiting for startMu
+	if a.server.RunningAt(address) {
+		return a.server.WaitHealthy(serverStartTimeout)
+	}
+	if serverListening(address) {
+		return nil
+	}
+
+	path, version, err := a.server.Locate(settings.Server.Path)
+	if errors.Is(err, errServerNotFound) {
+		if _, err := a.DownloadServer(); err != nil {
+			return errors.New("Couldn't download RLBotServer: " + err.Error())
+		}
+		path, version, err = a.server.Locate(settings.Server.Path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := a.server.Start(path, version, address); err != nil {
+		return errors.New("Couldn't start RLBotServer: " + err.Error())
+	}
+
+	return a.server.WaitHealthy(serverStartTimeout)
+}
+
+// ensureServer starts RLBotServer if the active server profile is on this computer,
+// nothing listens there and auto starting is on
+func (a *App) ensureServer() error {
+	settings := a.settings.Get()
+	address := settings.ServerAddress()
+	if !settings.Server.AutoStart || !isLocalAddress(address) || serverListening(address) {
+		return nil
+	}
+
+	return a.startServer()
+}
+
+func (a *App) GetServerProcessSettings() ServerProcessSettings {
+	return a.settings.Get().Server
+}
+
+func (a *App) SetServerProcessSettings(settings ServerProcessSettings) error {
+	if settings.Path != "" && !fileExists(settings.Path) {
+		return errors.New("RLBotServer not found at " + settings.Path)
+	}
+
+	return a.settings.Update(func(s *Settings) {
+		s.Server = settings
+	})
+}
+
+func (a *App) GetServerProcessStatus() ServerProcessStatus {
+	return a.server.Status()
+}
+
+func (a *App) StartServer() error {
+	if serverListening(a.rlbotAddress()) {
+		return errors.New("RLBotServer is already running at " + a.rlbotAddress())
+	}
+
+	return a.startServer()
+}
+
+func (a *App) StopServer() error {
+	return a.server.Stop()
+}
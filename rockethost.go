@@ -1,80 +1,16 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
 	rlbot "github.com/RLBot/go-interface"
 	"github.com/RLBot/go-interface/flat"
 )
 
-type RHostBot struct {
-	Name   string `json:"name"`
-	Family string `json:"family"`
-}
-
-func (a *App) GetRHostBots() ([]RHostBot, error) {
-	resp, err := http.Get("https://rocketleaguemaps.us/api/botList2.json")
-	if err != nil {
-		return nil, err
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("Error reading body: %v\n", err)
-		return nil, err
-	}
-
-	var data interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
-		fmt.Printf("Error parsing JSON: %v\n", err)
-		return nil, err
-	}
-
-	jsonBots, ok := data.([]interface{})
-	if !ok {
-		return nil, errors.New("invalid json shape: root isn't array")
-	}
-
-	bots := []RHostBot{}
-
-	for _, jsonBotOrFamily := range jsonBots {
-		if botString, ok := jsonBotOrFamily.(string); ok {
-			bots = append(bots, RHostBot{
-				Name:   botString,
-				Family: "",
-			})
-		} else if botFamily, ok := jsonBotOrFamily.(map[string]interface{}); ok {
-			familyName, ok := botFamily["name"].(string)
-			if !ok {
-				return nil, errors.New("invalid json shape: family object doesn't have \"name\"")
-			}
-
-			familyBots, ok := botFamily["versions"].([]interface{})
-			if !ok {
-				return nil, errors.New("invalid json shape: family object doesn't have \"versions\"")
-			}
-			for _, familyBot := range familyBots {
-				familyBotStr, ok := familyBot.(string)
-				if !ok {
-					return nil, errors.New("invalid json shape: \"versions\" isn't an array of strings")
-				}
-				bots = append(bots, RHostBot{
-					Name:   familyBotStr,
-					Family: familyName,
-				})
-			}
-		}
-	}
-
-	return bots, nil
-}
-
 type RHostServer struct {
 	Ip       string `json:"ip"`
 	Port     string `json:"port"`
@@ -83,94 +19,93 @@ type RHostServer struct {
 }
 
 func (a *App) GetRHostServers() ([]RHostServer, error) {
-	resp, err := http.Get("http://serverlist.jetfox.ovh/servers")
+	return a.rhostClient().Servers(context.Background())
+}
+
+type RHostMatchSettings struct {
+	// host:port of the server, or RHostAutoServer
+	Server string `json:"server"`
+	Map    string `json:"map"`
+	// Same names as StartMatchOptions, empty for soccar
+	GameMode        string                `json:"gameMode"`
+	MutatorSettings flat.MutatorSettingsT `json:"mutatorSettings"`
+	BlueBots        []string              `json:"blueBots"`
+	OrangeBots      []string              `json:"orangeBots"`
+	Launcher        string                `json:"launcher"`
+	LauncherArg     string                `json:"launcherArg"`
+	// How many more times to send the join command if the game doesn't join the match
+	JoinRetries int `json:"joinRetries"`
+}
+
+func (a *App) StartRHostMatch(settings RHostMatchSettings) (string, error) {
+	address, err := a.startRHostMatch(settings)
 	if err != nil {
-		return nil, err
+		emitRHostStatus(RHostStatus{State: RHostFailed, Message: err.Error()})
 	}
-	body, err := io.ReadAll(resp.Body)
+
+	return address, err
+}
+
+func (a *App) startRHostMatch(settings RHostMatchSettings) (string, error) {
+	request, gameMode, err := settings.request()
 	if err != nil {
-		fmt.Printf("Error reading body: %v\n", err)
-		return nil, err
+		return "", err
 	}
-	var data []RHostServer
-	if err := json.Unmarshal(body, &data); err != nil {
-		fmt.Printf("Error parsing JSON: %v\n", err)
-		return nil, err
+
+	launcher, launcherArg, err := resolveLauncherOrNoLaunch(settings.Launcher, settings.LauncherArg)
+	if err != nil {
+		return "", err
 	}
 
-	return data, nil
-}
+	if settings.JoinRetries < 0 {
+		return "", errors.New("join retries can't be negative")
+	}
 
-// /?mapName=ARC_Darc_P&gameMode=TAGame.GameInfo_Soccar_TA&
-// mutators=BotsNone,PlayerCount8&
-// networkOptions=?NumPublicConnections=10?NumOpenPublicConnections=10?Lan?Listen
-// &rlbot=yes&blueBots=FridgeV5&orangeBots=
+	// before asking for a RocketHost match, which would be left running if this fails
+	if err := a.ensureServer(); err != nil {
+		return "", err
+	}
 
-type RHostMatchSettings struct {
-	Server      string   `json:"server"`
-	Map         string   `json:"map"`
-	BlueBots    []string `json:"blueBots"`
-	OrangeBots  []string `json:"orangeBots"`
-	Launcher    string   `json:"launcher"`
-	LauncherArg string   `json:"launcherArg"`
-}
+	server := settings.Server
+	if server == RHostAutoServer {
+		emitRHostStatus(RHostStatus{State: RHostRequesting, Message: "Finding the fastest server"})
+		server, err = a.rhostClient().FastestServer(context.Background())
+		if err != nil {
+			return "", err
+		}
+		println("Using RocketHost server " + server)
+	}
 
-func (a *App) StartRHostMatch(settings RHostMatchSettings) (string, error) {
-	respRHostChan := make(chan Result)
+	emitRHostStatus(RHostStatus{State: RHostRequesting, Server: server})
+	respRHostChan := make(chan Result, 1)
 
 	// Request rockethost server
 	go func() {
-		blueBotsStr := strings.Join(settings.BlueBots, ",")
-		orangeBotsStr := strings.Join(settings.OrangeBots, ",")
-		resp, err :=
-			http.Get(fmt.Sprintf(
-				"http://%s/?mapName=%s&gameMode=TAGame.GameInfo_Soccar_TA&mutators=BotsNone,PlayerCount8&networkOptions=?NumPublicConnections=10?NumOpenPublicConnections=10?Lan?Listen&rlbot=yes&blueBots=%s&orangeBots=%s",
-				settings.Server, settings.Map, blueBotsStr, orangeBotsStr,
-			))
+		address, err := a.rhostClient().CreateMatch(context.Background(), server, request)
 		if err != nil {
 			respRHostChan <- Result{false, err.Error()}
 			return
 		}
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			respRHostChan <- Result{false, err.Error()}
-			return
-		}
-		respRHostChan <- Result{resp.StatusCode == 200, string(body)}
+		respRHostChan <- Result{true, address}
 	}()
 
 	// TODO: Save this in App struct
-	conn, err := rlbot.Connect(a.rlbotAddress)
+	conn, err := rlbot.Connect(a.rlbotAddress())
 	if err != nil {
-		return "", errors.New("Failed to connect to RLBotServer at " + a.rlbotAddress)
-	}
-
-	var launcher flat.Launcher
-	switch settings.Launcher {
-	case "steam":
-		launcher = flat.LauncherSteam
-	case "epic":
-		launcher = flat.LauncherEpic
-	case "custom":
-		launcher = flat.LauncherCustom
-	case "nolaunch":
-		launcher = flat.LauncherNoLaunch
-	default:
-		println("No launcher chosen, defaulting to NoLaunch")
-		launcher = flat.LauncherNoLaunch
+		return "", errors.New("Failed to connect to RLBotServer at " + a.rlbotAddress())
 	}
 
 	err = conn.SendPacket(&flat.MatchConfigurationT{
 		PlayerConfigurations:  []*flat.PlayerConfigurationT{},
 		ScriptConfigurations:  []*flat.ScriptConfigurationT{},
-		GameMode:              flat.GameModeSoccar,
-		Mutators:              &flat.MutatorSettingsT{},
+		GameMode:              gameMode,
+		Mutators:              &settings.MutatorSettings,
 		ExistingMatchBehavior: flat.ExistingMatchBehaviorRestart,
 		GameMapUpk:            settings.Map,
 		EnableStateSetting:    true,
 		EnableRendering:       flat.DebugRenderingOnByDefault,
 		Launcher:              launcher,
-		LauncherArg:           settings.LauncherArg,
+		LauncherArg:           launcherArg,
 	})
 	if err != nil {
 		return "", errors.New("Couldn't send matchconfiguration packet")
@@ -186,61 +121,22 @@ func (a *App) StartRHostMatch(settings RHostMatchSettings) (string, error) {
 		return "", errors.New("Couldn't send connectionsettings packet")
 	}
 
+	packetChan := make(chan any)
+	go ReadAllMessages(&conn, packetChan)
+	defer func() {
+		conn.SendPacket(&flat.DisconnectSignalT{})
+		go drainMessages(packetChan)
+	}()
+
 	println("Waiting for FieldInfo...")
-	for {
-		packet, err := conn.RecvPacket()
-		if err != nil {
-			return "", errors.New("Error reading packet from rlbotserver: " + err.Error())
-		}
-		_, ok := packet.Value.(*flat.FieldInfoT)
-		if ok {
-			break
-		}
+	if err := waitForFieldInfo(&conn, packetChan); err != nil {
+		return "", err
 	}
 
 	println("Waiting for RocketHost server...")
-	var result Result
-outer:
-	for {
-		select {
-		case result = <-respRHostChan:
-			break outer
-		default:
-			err = conn.SendPacket(&flat.RenderGroupT{
-				RenderMessages: []*flat.RenderMessageT{
-					{
-						Variety: &flat.RenderTypeT{
-							Type: flat.RenderTypeString2D,
-							Value: &flat.String2DT{
-								Text:  "Loading RocketHost game...",
-								X:     0.5,
-								Y:     0.5,
-								Scale: 1.5,
-								Foreground: &flat.ColorT{
-									R: 255,
-									G: 255,
-									B: 255,
-									A: 255,
-								},
-								Background: &flat.ColorT{
-									R: 0,
-									G: 0,
-									B: 0,
-									A: 255,
-								},
-								HAlign: flat.TextHAlignCenter,
-								VAlign: flat.TextVAlignCenter,
-							},
-						},
-					},
-				},
-				Id: 0,
-			})
-			if err != nil {
-				println("WARN: couldn't render loading message")
-			}
-			time.Sleep(1 * time.Second)
-		}
+	result, err := waitForRHostServer(&conn, packetChan, respRHostChan)
+	if err != nil {
+		return "", err
 	}
 
 	if !result.Success {
@@ -255,18 +151,43 @@ outer:
 		return "", errors.New(result.Message)
 	}
 
-	err = conn.SendPacket(&flat.DesiredGameStateT{
-		ConsoleCommands: []*flat.ConsoleCommandT{
-			{
-				Command: fmt.Sprintf("start %s/?Lan?Password=", result.Message),
+	address := result.Message
+	emitRHostStatus(RHostStatus{State: RHostServerReady, Server: server, Address: address})
+
+	attempts := settings.JoinRetries + 1
+	for attempt := 1; attempt <= attempts; attempt++ {
+		emitRHostStatus(RHostStatus{
+			State:    RHostJoining,
+			Server:   server,
+			Address:  address,
+			Attempt:  attempt,
+			Attempts: attempts,
+		})
+
+		err = conn.SendPacket(&flat.DesiredGameStateT{
+			ConsoleCommands: []*flat.ConsoleCommandT{
+				{
+					Command: fmt.Sprintf("start %s/?Lan?Password=", address),
+				},
 			},
-		},
-	})
-	if err != nil {
-		return "", errors.New("Couldn't send join message")
-	}
+		})
+		if err != nil {
+			return "", errors.New("Couldn't send join message")
+		}
+
+		joined, err := waitForRHostJoin(packetChan, rhostJoinTimeout)
+		if err != nil {
+			return "", err
+		}
+
+		if joined {
+			emitRHostStatus(RHostStatus{State: RHostInMatch, Server: server, Address: address, Attempt: attempt, Attempts: attempts})
+			return address, nil
+		}
 
-	conn.SendPacket(&flat.DisconnectSignalT{})
+		println("WARN: didn't join the RocketHost match at " + address + " after " + rhostJoinTimeout.String() +
+			" (attempt " + strconv.Itoa(attempt) + " of " + strconv.Itoa(attempts) + ")")
+	}
 
-	return result.Message, nil
+	return "", fmt.Errorf("Couldn't join the RocketHost match at %s after %d attempts", address, attempts)
 }
@@ -4,7 +4,6 @@ import (
 	"encoding/base64"
 	"os"
 	"path/filepath"
-	"runtime"
 	"sort"
 
 	"github.com/BurntSushi/toml"
@@ -13,18 +12,11 @@ import (
 )
 
 func (botInfo BotInfo) ToScriptConfig() *flat.ScriptConfigurationT {
-	var runCommand string
-	if runtime.GOOS == "windows" {
-		runCommand = botInfo.Config.Settings.RunCommand
-	} else if runtime.GOOS == "linux" {
-		runCommand = botInfo.Config.Settings.RunCommandLinux
-	}
-
 	return &flat.ScriptConfigurationT{
 		Name:       botInfo.Config.Settings.Name,
 		AgentId:    botInfo.Config.Settings.AgentId,
 		RootDir:    botInfo.Config.Settings.RootDir,
-		RunCommand: runCommand,
+		RunCommand: botInfo.RunCommand(),
 		ScriptId:   0, // let core do this
 	}
 }
@@ -75,9 +67,12 @@ func (a *App) GetScripts(paths []string) []BotInfo {
 			conf.Settings.LogoFile = "data:" + mtype.String() + ";base64," + b64data
 		}
 
+		runner := a.settings.Get().Wine.ResolveRunner(conf.Settings)
+
 		infos = append(infos, BotInfo{
 			Config:   conf,
 			TomlPath: potentialConfigPath,
+			Runner:   &runner,
 		})
 	}
 
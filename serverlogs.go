@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// entries of the RLBotServer log kept in memory for the console
+const serverLogEntries = 5000
+
+type ServerLogLevel string
+
+const (
+	ServerLogTrace    ServerLogLevel = "trace"
+	ServerLogDebug    ServerLogLevel = "debug"
+	ServerLogInfo     ServerLogLevel = "info"
+	ServerLogWarn     ServerLogLevel = "warn"
+	ServerLogError    ServerLogLevel = "error"
+	ServerLogCritical ServerLogLevel = "critical"
+)
+
+var serverLogLevels = []ServerLogLevel{
+	ServerLogTrace,
+	ServerLogDebug,
+	ServerLogInfo,
+	ServerLogWarn,
+	ServerLogError,
+	ServerLogCritical,
+}
+
+// the ways levels are written, including the short ones of .NET console logging
+var serverLogLevelNames = map[string]ServerLogLevel{
+	"trace":       ServerLogTrace,
+	"trce":        ServerLogTrace,
+	"debug":       ServerLogDebug,
+	"dbug":        ServerLogDebug,
+	"info":        ServerLogInfo,
+	"information": ServerLogInfo,
+	"warn":        ServerLogWarn,
+	"warning":     ServerLogWarn,
+	"error":       ServerLogError,
+	"fail":        ServerLogError,
+	"crit":        ServerLogCritical,
+	"critical":    ServerLogCritical,
+	"fatal":       ServerLogCritical,
+}
+
+const serverLogLevelWords = "trace|trce|debug|dbug|information|info|warning|warn|error|fail|critical|crit|fatal"
+
+var (
+	serverLogTimeRe = regexp.MustCompile(
+		`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?|\d{2}:\d{2}:\d{2}(?:[.,]\d+)?)\]?\s*`,
+	)
+	// [level], level: or LEVEL followed by a space, so that messages starting with a level stay whole
+	serverLogLevelRe = regexp.MustCompile(
+		`^(?:(?i:\[\s*(` + serverLogLevelWords + `)\s*\]:?|(` + serverLogLevelWords + `):)|(` +
+			strings.ToUpper(serverLogLevelWords) + `)\s)\s*`,
+	)
+	// [Component] or Component: or Component[eventId]:
+	serverLogComponentRe = regexp.MustCompile(`^(?:\[([^\]]+)\]:?\s*|([A-Za-z][\w.]*)(?:\[\d+\]:?|:)(?:\s+|$))`)
+)
+
+// ServerLogEntry is a line of RLBotServer output, sent to the frontend as the "server-log" event
+type ServerLogEntry struct {
+	// Increases by one with every entry, to find the new ones
+	Seq uint64 `json:"seq"`
+	// From the line if it has one, otherwise when the GUI read it
+	Time      time.Time      `json:"time"`
+	Level     ServerLogLevel `json:"level"`
+	Component string         `json:"component"`
+	Message   string         `json:"message"`
+	// stdout, stderr or gui for the lines of the GUI itself
+	Stream string `json:"stream"`
+}
+
+func parseServerLogTime(value string, now time.Time) (time.Time, bool) {
+	value = strings.Replace(value, ",", ".", 1)
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999Z0700", "2006-01-02 15:04:05.999999999Z07:00"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, true
+		}
+	}
+
+	// only a time of day, from today
+	if t, err := time.ParseInLocation("15:04:05.999999999", value, now.Location()); err == nil {
+		year, month, day := now.Date()
+		return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), now.Location()), true
+	}
+
+	return time.Time{}, false
+}
+
+// ParseServerLogLine turns a line of RLBotServer output into an entry. Indented lines
+// without a level, like stack traces, belong to previous. Lines without a level
+// are errors on stderr and info on stdout.
+func ParseServerLogLine(line string, stream string, now time.Time, previous *ServerLogEntry) ServerLogEntry {
+	entry := ServerLogEntry{Time: now, Stream: stream}
+	rest := strings.TrimRight(line, "\r\n")
+
+	if m := serverLogTimeRe.FindStringSubmatch(rest); m != nil {
+		if t, ok := parseServerLogTime(m[1], now); ok {
+			entry.Time = t
+			rest = rest[len(m[0]):]
+		}
+	}
+
+	if m := serverLogLevelRe.FindStringSubmatch(rest); m != nil {
+		entry.Level = serverLogLevelNames[strings.ToLower(m[1]+m[2]+m[3])]
+		rest = rest[len(m[0]):]
+
+		if m := serverLogComponentRe.FindStringSubmatch(rest); m != nil {
+			entry.Component = strings.TrimSpace(m[1] + m[2])
+			rest = rest[len(m[0]):]
+		}
+	}
+
+	continuation := rest != strings.TrimLeft(rest, " \t")
+	if entry.Level == "" && continuation && previous != nil && previous.Stream == stream {
+		entry.Level = previous.Level
+		entry.Component = previous.Component
+		entry.Time = previous.Time
+	}
+
+	if entry.Level == "" {
+		if stream == "stderr" {
+			entry.Level = ServerLogError
+		} else {
+			entry.Level = ServerLogInfo
+		}
+	}
+
+	entry.Message = rest
+	if !continuation {
+		entry.Message = strings.TrimSpace(rest)
+	}
+
+	return entry
+}
+
+func (entry ServerLogEntry) String() string {
+	component := entry.Component
+	if component == "" {
+		component = "-"
+	}
+
+	return fmt.Sprintf(
+		"%s %-8s [%s] [%s] %s",
+		entry.Time.Format(time.RFC3339Nano),
+		strings.ToUpper(string(entry.Level)),
+		entry.Stream,
+		component,
+		entry.Message,
+	)
+}
+
+func serverLogRank(level ServerLogLevel) int {
+	return slices.Index(serverLogLevels, level)
+}
+
+type ServerLogFilter struct {
+	// Lowest level to show, empty for every level
+	MinLevel ServerLogLevel `json:"minLevel"`
+	// Components to show, empty for every component
+	Components []string `json:"components"`
+	// Text the message or component has to contain, case insensitive
+	Text string `json:"text"`
+}
+
+func (filter ServerLogFilter) Matches(entry ServerLogEntry) bool {
+	if filter.MinLevel != "" && serverLogRank(entry.Level) < serverLogRank(filter.MinLevel) {
+		return false
+	}
+
+	if len(filter.Components) > 0 && !slices.Contains(filter.Components, entry.Component) {
+		return false
+	}
+
+	if filter.Text != "" {
+		text := strings.ToLower(filter.Text)
+		if !strings.Contains(strings.ToLower(entry.Message), text) &&
+			!strings.Contains(strings.ToLower(entry.Component), text) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ServerLog keeps the last entries of the RLBotServer output
+type ServerLog struct {
+	mu sync.Mutex
+	// ring of at most serverLogEntries, the oldest at start once it's full
+	entries []ServerLogEntry
+	start   int
+	seq     uint64
+	// last entry of every stream, for lines that continue it
+	last map[string]*ServerLogEntry
+}
+
+func NewServerLog() *ServerLog {
+	return &ServerLog{last: map[string]*ServerLogEntry{}}
+}
+
+// Add parses line and keeps the entry
+func (l *ServerLog) Add(line string, stream string) ServerLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := ParseServerLogLine(line, stream, time.Now(), l.last[stream])
+	l.seq++
+	entry.Seq = l.seq
+	l.last[stream] = &entry
+
+	if len(l.entries) < serverLogEntries {
+		l.entries = append(l.entries, entry)
+	} else {
+		l.entries[l.start] = entry
+		l.start = (l.start + 1) % len(l.entries)
+	}
+
+	return entry
+}
+
+// at returns the i-th kept entry, oldest first
+func (l *ServerLog) at(i int) ServerLogEntry {
+	return l.entries[(l.start+i)%len(l.entries)]
+}
+
+// Entries returns the last maxEntries entries after the one numbered after that match filter
+func (l *ServerLog) Entries(filter ServerLogFilter, after uint64, maxEntries int) []ServerLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := []ServerLogEntry{}
+	for i := len(l.entries) - 1; i >= 0 && len(entries) < maxEntries; i-- {
+		entry := l.at(i)
+		if entry.Seq <= after {
+			break
+		}
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	slices.Reverse(entries)
+
+	return entries
+}
+
+// Components lists the components seen in the kept entries
+func (l *ServerLog) Components() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	components := []string{}
+	for _, entry := range l.entries {
+		if entry.Component != "" && !slices.Contains(components, entry.Component) {
+			components = append(components, entry.Component)
+		}
+	}
+	slices.Sort(components)
+
+	return components
+}
+
+// GetServerLogs returns the last maxEntries entries that match filter,
+// only the ones numbered higher than after
+func (a *App) GetServerLogs(filter ServerLogFilter, after uint64, maxEntries int) []ServerLogEntry {
+	return a.server.logs.Entries(filter, after, maxEntries)
+}
+
+func (a *App) GetServerLogComponents() []string {
+	return a.server.logs.Components()
+}
+
+// GetServerLogPath returns the file the RLBotServer output is kept in
+func (a *App) GetServerLogPath() string {
+	return a.server.logPath
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseServerLogLine(t *testing.T) {
+	now := time.Date(2025, 3, 14, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		lines  []string
+		stream string
+		// stream of the lines before the last one, stream if empty
+		previousStream string
+		// the entry of the last line
+		want ServerLogEntry
+	}{
+		{
+			name: ".NET console logger",
+			lines: []string{
+				"info: RLBotCS.Server.FlatBuffersServer[0]",
+				"      Server listening on port 23234",
+			},
+			stream: "stdout",
+			want: ServerLogEntry{
+				Time:      now,
+				Level:     ServerLogInfo,
+				Component: "RLBotCS.Server.FlatBuffersServer",
+				Message:   "      Server listening on port 23234",
+			},
+		},
+		{
+			name:   ".NET console logger, single line",
+			lines:  []string{"warn: RLBotCS.ManagerTools.LaunchManager[0] Rocket League is already running"},
+			stream: "stdout",
+			want: ServerLogEntry{
+				Time:      now,
+				Level:     ServerLogWarn,
+				Component: "RLBotCS.ManagerTools.LaunchManager",
+				Message:   "Rocket League is already running",
+			},
+		},
+		{
+			name: ".NET failure with a stack trace",
+			lines: []string{
+				"fail: RLBotCS.Server.FlatBuffersSession[0]",
+				"      System.IO.EndOfStreamException: Unable to read beyond the end of the stream.",
+				"         at RLBotCS.Server.FlatBuffersSession.HandleClientMessages()",
+			},
+			stream: "stdout",
+			want: ServerLogEntry{
+				Time:      now,
+				Level:     ServerLogError,
+				Component: "RLBotCS.Server.FlatBuffersSession",
+				Message:   "         at RLBotCS.Server.FlatBuffersSession.HandleClientMessages()",
+			},
+		},
+		{
+			name:   "timestamp, uppercase level and bracketed component",
+			lines:  []string{"2025-03-14 08:59:58.125 INFO [MatchStarter] Starting match on Mannfield"},
+			stream: "stdout",
+			want: ServerLogEntry{
+				Time:      time.Date(2025, 3, 14, 8, 59, 58, 125000000, time.UTC),
+				Level:     ServerLogInfo,
+				Component: "MatchStarter",
+				Message:   "Starting match on Mannfield",
+			},
+		},
+		{
+			name:   "time of day and bracketed level",
+			lines:  []string{"[08:59:58] [warning] [Bridge]: Game didn't respond"},
+			stream: "stdout",
+			want: ServerLogEntry{
+				Time:      time.Date(2025, 3, 14, 8, 59, 58, 0, time.UTC),
+				Level:     ServerLogWarn,
+				Component: "Bridge",
+				Message:   "Game didn't respond",
+			},
+		},
+		{
+			name:   "message that starts with a level word",
+			lines:  []string{"Information about the match was sent"},
+			stream: "stdout",
+			want: ServerLogEntry{
+				Time:    now,
+				Level:   ServerLogInfo,
+				Message: "Information about the match was sent",
+			},
+		},
+		{
+			name:   "unformatted line on stderr",
+			lines:  []string{"Unhandled exception. System.Net.Sockets.SocketException (98): Address already in use"},
+			stream: "stderr",
+			want: ServerLogEntry{
+				Time:    now,
+				Level:   ServerLogError,
+				Message: "Unhandled exception. System.Net.Sockets.SocketException (98): Address already in use",
+			},
+		},
+		{
+			name: "indented line of another stream",
+			lines: []string{
+				"warn: RLBotCS.Server.BridgeHandler[0]",
+				"   at System.Net.Sockets.Socket.Bind(EndPoint localEP)",
+			},
+			stream:         "stderr",
+			previousStream: "stdout",
+			want: ServerLogEntry{
+				Time:    now,
+				Level:   ServerLogError,
+				Message: "   at System.Net.Sockets.Socket.Bind(EndPoint localEP)",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var previous *ServerLogEntry
+			var entry ServerLogEntry
+			for i, line := range test.lines {
+				stream := test.stream
+				if i < len(test.lines)-1 && test.previousStream != "" {
+					stream = test.previousStream
+				}
+
+				entry = ParseServerLogLine(line, stream, now, previous)
+				previous = &entry
+			}
+
+			test.want.Stream = test.stream
+			if !entry.Time.Equal(test.want.Time) {
+				t.Errorf("got time %s, want %s", entry.Time, test.want.Time)
+			}
+			entry.Time = test.want.Time
+			if entry != test.want {
+				t.Errorf("got %+v, want %+v", entry, test.want)
+			}
+		})
+	}
+}
+
+func TestServerLogEntries(t *testing.T) {
+	log := NewServerLog()
+	log.Add("info: RLBotCS.Server.FlatBuffersServer[0] listening", "stdout")
+	log.Add("dbug: RLBotCS.ManagerTools.LaunchManager[0] looking for the game", "stdout")
+	log.Add("fail: RLBotCS.Server.FlatBuffersServer[0] crashed", "stdout")
+
+	entries := log.Entries(ServerLogFilter{MinLevel: ServerLogInfo}, 0, 10)
+	if len(entries) != 2 || entries[0].Message != "listening" || entries[1].Message != "crashed" {
+		t.Fatalf("got %+v, want the info and fail entries", entries)
+	}
+
+	newer := log.Entries(ServerLogFilter{}, entries[0].Seq, 10)
+	if len(newer) != 2 || newer[0].Level != ServerLogDebug {
+		t.Errorf("got %+v, want the entries after the first", newer)
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	rlbot "github.com/RLBot/go-interface"
+	"github.com/RLBot/go-interface/flat"
+)
+
+const (
+	defaultServerProfile = "local"
+	defaultRLBotAddress  = "127.0.0.1:23234"
+
+	// how long a server that is reachable gets to answer the handshake with its match
+	serverMatchTimeout = 2 * time.Second
+)
+
+// environment variables that override the address of the active server profile
+const (
+	rlbotServerIpEnv   = "RLBOT_SERVER_IP"
+	rlbotServerPortEnv = "RLBOT_SERVER_PORT"
+)
+
+// ServerProfile is an RLBotServer that the GUI can send matches to
+type ServerProfile struct {
+	Name string `toml:"name" json:"name"`
+	// host:port of the server
+	Address string `toml:"address" json:"address"`
+}
+
+func (p ServerProfile) Validate() error {
+	if p.Name == "" {
+		return errors.New("server profiles need a name")
+	}
+
+	host, port, err := net.SplitHostPort(p.Address)
+	if err != nil {
+		return errors.New("invalid address of server profile " + p.Name + ": " + err.Error())
+	}
+	if host == "" {
+		return errors.New("server profile " + p.Name + " has no host")
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return errors.New("server profile " + p.Name + " has an invalid port")
+	}
+
+	return nil
+}
+
+func validateServerProfiles(profiles []ServerProfile, active string) error {
+	if len(profiles) == 0 {
+		return errors.New("at least one server profile is needed")
+	}
+
+	names := map[string]bool{}
+	for _, profile := range profiles {
+		if err := profile.Validate(); err != nil {
+			return err
+		}
+		if names[profile.Name] {
+			return errors.New("there are two server profiles named " + profile.Name)
+		}
+		names[profile.Name] = true
+	}
+
+	if active != "" && !names[active] {
+		return errors.New("there is no server profile named " + active)
+	}
+
+	return nil
+}
+
+// ActiveServerProfile returns the chosen server profile, the first one if none is chosen
+func (s Settings) ActiveServerProfile() ServerProfile {
+	for _, profile := range s.ServerProfiles {
+		if profile.Name == s.ActiveServer {
+			return profile
+		}
+	}
+
+	if len(s.ServerProfiles) > 0 {
+		return s.ServerProfiles[0]
+	}
+
+	return ServerProfile{Name: defaultServerProfile, Address: defaultRLBotAddress}
+}
+
+// ServerAddress returns where matches are sent, see ServerProfile.EffectiveAddress
+func (s Settings) ServerAddress() string {
+	return s.ActiveServerProfile().EffectiveAddress()
+}
+
+// EffectiveAddress returns where matches are sent while the profile is active,
+// its address with the ip and port from the environment if they're set
+func (p ServerProfile) EffectiveAddress() string {
+	host, port, err := net.SplitHostPort(p.Address)
+	if err != nil {
+		host, port, _ = net.SplitHostPort(defaultRLBotAddress)
+	}
+
+	if ip := os.Getenv(rlbotServerIpEnv); ip != "" {
+		host = ip
+	}
+	if p := os.Getenv(rlbotServerPortEnv); p != "" {
+		port = p
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// ServerMatch is what a server reports about the match it's running
+type ServerMatch struct {
+	Map      string `json:"map"`
+	GameMode string `json:"gameMode"`
+	Players  int    `json:"players"`
+	Scripts  int    `json:"scripts"`
+	// Empty if the server didn't send a game packet in time
+	Phase string `json:"phase"`
+}
+
+type ServerStatus struct {
+	Profile ServerProfile `json:"profile"`
+	Address string        `json:"address"`
+	// The server answered the handshake with a packet
+	Reachable bool `json:"reachable"`
+	// Time to open a TCP connection to the server
+	LatencyMs float64 `json:"latencyMs"`
+	// RLBotServer doesn't send its version, so it's only known for servers the GUI runs itself
+	Version string `json:"version"`
+	// nil if the server isn't running a match
+	Match *ServerMatch `json:"match"`
+	// Why the server isn't reachable
+	Error string `json:"error"`
+}
+
+// waitForServerMatch reads what the server sends after the handshake,
+// a match configuration and then game packets if it's running a match.
+// answered is set once the server sent one of those packets.
+func waitForServerMatch(conn *rlbot.RLBotConnection, packetChan chan any, timeout time.Duration) (match *ServerMatch, answered bool, err error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case item := <-packetChan:
+			if err, ok := item.(error); ok {
+				return match, answered, err
+			}
+
+			switch packet := item.(type) {
+			case *flat.MatchConfigurationT:
+				answered = true
+				match = &ServerMatch{
+					Map:      packet.GameMapUpk,
+					GameMode: packet.GameMode.String(),
+					Players:  len(packet.PlayerConfigurations),
+					Scripts:  len(packet.ScriptConfigurations),
+				}
+			case *flat.FieldInfoT:
+				answered = true
+				conn.SendPacket(&flat.InitCompleteT{})
+			case *flat.GamePacketT:
+				answered = true
+				if match != nil && packet.MatchInfo != nil {
+					match.Phase = packet.MatchInfo.MatchPhase.String()
+					return match, answered, nil
+				}
+			case *flat.DisconnectSignalT:
+				return match, answered, nil
+			}
+		case <-timer.C:
+			return match, answered, nil
+		}
+	}
+}
+
+// CheckServer connects to the RLBotServer at address like an agent would
+// and reports what it's doing
+func CheckServer(ctx context.Context, address string) ServerStatus {
+	status := ServerStatus{Address: address}
+
+	// rlbot.Connect has no timeout, so find out if anything listens first
+	latency, err := probeLatency(ctx, address)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.LatencyMs = float64(latency.Microseconds()) / 1000
+
+	conn, err := rlbot.Connect(address)
+	if err != nil {
+		status.Error = "Failed to connect to RLBotServer at " + address + ": " + err.Error()
+		return status
+	}
+
+	packetChan := make(chan any)
+	go ReadAllMessages(&conn, packetChan)
+
+	// RLBotConnection has no Close, the server closes the connection once it's told to disconnect.
+	// If the handshake failed, reading fails too and the reader stops.
+	defer func() {
+		conn.SendPacket(&flat.DisconnectSignalT{})
+		go drainMessages(packetChan)
+	}()
+
+	err = conn.SendPacket(&flat.ConnectionSettingsT{
+		AgentId:              "",
+		WantsBallPredictions: false,
+		WantsComms:           false,
+		CloseBetweenMatches:  true,
+	})
+	if err != nil {
+		status.Error = "Handshake with RLBotServer at " + address + " failed: " + err.Error()
+		return status
+	}
+
+	// writing to the socket isn't enough, the server has to answer
+	match, answered, err := waitForServerMatch(&conn, packetChan, serverMatchTimeout)
+	if err != nil {
+		status.Error = "Error reading packet from rlbotserver: " + err.Error()
+	} else if !answered {
+		status.Error = "RLBotServer at " + address + " didn't answer the handshake within " + serverMatchTimeout.String()
+	}
+	status.Reachable = answered
+	status.Match = match
+
+	return status
+}
+
+func (a *App) rlbotAddress() string {
+	return a.settings.Get().ServerAddress()
+}
+
+func (a *App) GetServerProfiles() []ServerProfile {
+	return a.settings.Get().ServerProfiles
+}
+
+func (a *App) GetActiveServerProfile() ServerProfile {
+	return a.settings.Get().ActiveServerProfile()
+}
+
+// GetServerOverride returns the environment variables that override
+// the address of the active server profile, empty if there are none
+func (a *App) GetServerOverride() string {
+	override := ""
+	for _, env := range []string{rlbotServerIpEnv, rlbotServerPortEnv} {
+		if value := os.Getenv(env); value != "" {
+			if override != "" {
+				override += " "
+			}
+			override += env + "=" + value
+		}
+	}
+
+	return override
+}
+
+// GetServerProfileAddresses returns where matches are sent with each of profiles active,
+// which only differs from their addresses if GetServerOverride isn't empty
+func (a *App) GetServerProfileAddresses(profiles []ServerProfile) []string {
+	addresses := make([]string, len(profiles))
+	for i, profile := range profiles {
+		addresses[i] = profile.EffectiveAddress()
+	}
+
+	return addresses
+}
+
+// SetServerProfiles replaces the server profiles and switches to the one named active
+func (a *App) SetServerProfiles(profiles []ServerProfile, active string) error {
+	if err := validateServerProfiles(profiles, active); err != nil {
+		return err
+	}
+
+	err := a.settings.Update(func(s *Settings) {
+		s.ServerProfiles = profiles
+		s.ActiveServer = active
+	})
+	if err != nil {
+		return err
+	}
+
+	a.applySettings(a.settings.Get())
+	return nil
+}
+
+// SelectServerProfile switches to the server profile named name,
+// the next match is sent to that server
+func (a *App) SelectServerProfile(name string) error {
+	err := a.settings.Update(func(s *Settings) {
+		s.ActiveServer = name
+	})
+	if err != nil {
+		return err
+	}
+
+	a.applySettings(a.settings.Get())
+	return nil
+}
+
+// CheckServerProfile does the handshake with the server of profile,
+// which doesn't have to be saved yet
+func (a *App) CheckServerProfile(profile ServerProfile) ServerStatus {
+	if err := profile.Validate(); err != nil {
+		return ServerStatus{Profile: profile, Address: profile.Address, Error: err.Error()}
+	}
+
+	// the environment may send matches somewhere else than the profile says
+	address := profile.EffectiveAddress()
+	status := CheckServer(context.Background(), address)
+	status.Profile = profile
+
+	running := a.server.Status()
+	if running.Running && isLocalAddress(address) && isLocalAddress(running.Address) {
+		_, port, _ := net.SplitHostPort(address)
+		_, runningPort, _ := net.SplitHostPort(running.Address)
+		if port == runningPort {
+			status.Version = running.Version
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ncruces/zenity"
+)
+
+// version of the settings file written by this GUI, see settingsMigrations
+const settingsVersion = 1
+
+type BotSearchPath struct {
+	InstallPath string `toml:"install_path" json:"installPath"`
+	Visible     bool   `toml:"visible" json:"visible"`
+	// Repo and release of a downloaded botpack, empty for folders added by hand
+	Repo    string `toml:"repo" json:"repo"`
+	TagName string `toml:"tag_name" json:"tagName"`
+}
+
+type MatchSettings struct {
+	Map          string `toml:"map" json:"map"`
+	GameMode     string `toml:"game_mode" json:"gameMode"`
+	RandomizeMap bool   `toml:"randomize_map" json:"randomizeMap"`
+	Launcher     string `toml:"launcher" json:"launcher"`
+	LauncherArg  string `toml:"launcher_arg" json:"launcherArg"`
+	// Index of the chosen option of every mutator, by json name of the mutator
+	Mutators     map[string]int `toml:"mutators" json:"mutators"`
+	ExtraOptions ExtraOptions   `toml:"extra_options" json:"extraOptions"`
+	// tomlPath of the enabled scripts
+	EnabledScripts []string `toml:"enabled_scripts" json:"enabledScripts"`
+}
+
+type RHostMatchPreferences struct {
+	// host:port of the server, or RHostAutoServer
+	Server      string         `toml:"server" json:"server"`
+	GameMode    string         `toml:"game_mode" json:"gameMode"`
+	Mutators    map[string]int `toml:"mutators" json:"mutators"`
+	JoinRetries int            `toml:"join_retries" json:"joinRetries"`
+}
+
+type LoadoutEditorSettings struct {
+	ShowcaseType    string `toml:"showcase_type" json:"showcaseType"`
+	PreviewOnChange bool   `toml:"preview_on_change" json:"previewOnChange"`
+	// Where the item database was last updated from
+	ItemsSource string `toml:"items_source" json:"itemsSource"`
+}
+
+type Settings struct {
+	Version int `toml:"version" json:"version"`
+	// RLBotServers the GUI can send matches to, and the name of the one it does
+	ServerProfiles []ServerProfile `toml:"server_profiles" json:"serverProfiles"`
+	ActiveServer   string          `toml:"active_server" json:"activeServer"`
+	// How the GUI runs RLBotServer on this computer
+	Server        ServerProcessSettings `toml:"server" json:"server"`
+	BotPaths      []BotSearchPath       `toml:"bot_paths" json:"botPaths"`
+	Favorites     []string              `toml:"favorites" json:"favorites"`
+	Match         MatchSettings         `toml:"match" json:"match"`
+	RocketHost    RHostSettings         `toml:"rockethost" json:"rocketHost"`
+	RHostMatch    RHostMatchPreferences `toml:"rockethost_match" json:"rhostMatch"`
+	LoadoutEditor LoadoutEditorSettings `toml:"loadout_editor" json:"loadoutEditor"`
+	Wine          WineSettings          `toml:"wine" json:"wine"`
+	// How the GUI restarts and limits the bots and scripts it runs
+	Supervisor SupervisorSettings `toml:"supervisor" json:"supervisor"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		Version: settingsVersion,
+		ServerProfiles: []ServerProfile{
+			{Name: defaultServerProfile, Address: defaultRLBotAddress},
+		},
+		ActiveServer: defaultServerProfile,
+		Server:       DefaultServerProcessSettings(),
+		BotPaths:     []BotSearchPath{},
+		Favorites:    []string{},
+		Match: MatchSettings{
+			GameMode:       "Soccar",
+			Mutators:       map[string]int{},
+			EnabledScripts: []string{},
+		},
+		RHostMatch: RHostMatchPreferences{
+			Server:      RHostAutoServer,
+			GameMode:    "Soccar",
+			Mutators:    map[string]int{},
+			JoinRetries: 2,
+		},
+		LoadoutEditor: LoadoutEditorSettings{ShowcaseType: "static"},
+		Wine:          WineSettings{Runner: RunnerWine},
+		Supervisor:    DefaultSupervisorSettings(),
+	}
+}
+
+// Validate checks the values that would break the GUI. The wine install isn't checked,
+// since it may not exist on this machine when importing settings from another one.
+func (s Settings) Validate() error {
+	if err := validateServerProfiles(s.ServerProfiles, s.ActiveServer); err != nil {
+		return err
+	}
+	if err := s.RocketHost.Validate(); err != nil {
+		return err
+	}
+	if s.RHostMatch.JoinRetries < 0 {
+		return errors.New("join retries can't be negative")
+	}
+	if err := s.Supervisor.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// settingsMigrations[v-1] turns a settings file of version v into one of version v+1.
+// They work on the raw toml so they can rename and move keys.
+var settingsMigrations = []func(raw map[string]any) error{}
+
+// migrateSettings upgrades raw to settingsVersion. Files without a version are of the first one.
+func migrateSettings(raw map[string]any) error {
+	version := 1
+	if v, ok := raw["version"].(int64); ok {
+		version = int(v)
+	}
+
+	if version > settingsVersion {
+		return fmt.Errorf("the settings are from a newer GUI (version %d, this GUI knows up to %d)", version, settingsVersion)
+	}
+	if version < 1 {
+		return fmt.Errorf("invalid settings version %d", version)
+	}
+
+	for ; version < settingsVersion; version++ {
+		if err := settingsMigrations[version-1](raw); err != nil {
+			return fmt.Errorf("migrating settings from version %d: %w", version, err)
+		}
+	}
+
+	raw["version"] = settingsVersion
+	return nil
+}
+
+// ParseSettings reads a settings file of any version, filling in defaults for missing values
+func ParseSettings(data []byte) (Settings, error) {
+	raw := map[string]any{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return Settings{}, err
+	}
+
+	if err := migrateSettings(raw); err != nil {
+		return Settings{}, err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return Settings{}, err
+	}
+
+	settings := DefaultSettings()
+	if _, err := toml.Decode(buf.String(), &settings); err != nil {
+		return Settings{}, err
+	}
+
+	return settings, nil
+}
+
+// SettingsStore keeps the settings of the GUI in a toml file
+type SettingsStore struct {
+	mu       sync.Mutex
+	path     string
+	settings Settings
+}
+
+// LoadSettingsStore reads the settings at path, migrating them if they're from an older GUI.
+// Missing or broken files give the default settings.
+func LoadSettingsStore(path string) *SettingsStore {
+	store := &SettingsStore{path: path, settings: DefaultSettings()}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store
+	} else if err != nil {
+		println("WARN: failed to read settings at " + path + ": " + err.Error())
+		return store
+	}
+
+	settings, err := ParseSettings(data)
+	if err != nil {
+		println("WARN: failed to load settings at " + path + ": " + err.Error())
+		return store
+	}
+
+	store.settings = settings
+	return store
+}
+
+func (store *SettingsStore) Get() Settings {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.settings
+}
+
+func writeSettings(path string, settings Settings) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := toml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	// don't leave a half written file if the GUI gets closed while saving
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// set saves settings, store.mu must be held
+func (store *SettingsStore) set(settings Settings) error {
+	settings.Version = settingsVersion
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	if err := writeSettings(store.path, settings); err != nil {
+		return err
+	}
+
+	store.settings = settings
+	return nil
+}
+
+func (store *SettingsStore) Set(settings Settings) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.set(settings)
+}
+
+// Update changes the settings with update and saves them
+func (store *SettingsStore) Update(update func(*Settings)) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	settings := store.settings
+	update(&settings)
+	return store.set(settings)
+}
+
+func (store *SettingsStore) Export(path string) error {
+	return writeSettings(path, store.Get())
+}
+
+// Import replaces the settings with the ones at path, which may be from another GUI version
+func (store *SettingsStore) Import(path string) (Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	settings, err := ParseSettings(data)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	return settings, store.Set(settings)
+}
+
+func (a *App) settingsPath() string {
+	return filepath.Join(a.GetDefaultPath(), "settings.toml")
+}
+
+// rhostClient returns the RocketHost client of the current settings
+func (a *App) rhostClient() *RHostClient {
+	return a.rhost.Load()
+}
+
+// applySettings updates the parts of the App that depend on the settings
+func (a *App) applySettings(settings Settings) {
+	a.rhost.Store(NewRHostClient(settings.RocketHost.Endpoints()))
+	a.agents.SetRLBotAddress(settings.ServerAddress())
+	a.agents.SetSettings(settings.Supervisor)
+}
+
+func (a *App) GetSettings() Settings {
+	return a.settings.Get()
+}
+
+func (a *App) SetSettings(settings Settings) error {
+	if err := a.settings.Set(settings); err != nil {
+		return err
+	}
+
+	a.applySettings(settings)
+	return nil
+}
+
+// ExportSettings asks where to save a copy of the settings. Returns the path, empty if cancelled.
+func (a *App) ExportSettings() (string, error) {
+	path, err := zenity.SelectFileSave(
+		zenity.Filename("rlbotgui-settings.toml"),
+		zenity.ConfirmOverwrite(),
+		zenity.FileFilter{Name: ".toml files", Patterns: []string{"*.toml"}},
+	)
+	if errors.Is(err, zenity.ErrCanceled) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return path, a.settings.Export(path)
+}
+
+// ImportSettings asks for a settings file and replaces the settings with it
+func (a *App) ImportSettings() (Settings, error) {
+	path, err := zenity.SelectFile(zenity.FileFilter{
+		Name:     ".toml files",
+		Patterns: []string{"*.toml"},
+	})
+	if errors.Is(err, zenity.ErrCanceled) {
+		return a.settings.Get(), nil
+	} else if err != nil {
+		return Settings{}, err
+	}
+
+	settings, err := a.settings.Import(path)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	a.applySettings(settings)
+	return settings, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSettingsDefaults(t *testing.T) {
+	settings, err := ParseSettings([]byte("favorites = [\"bot.toml\"]\n\n[match]\nmap = \"Mannfield\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if settings.Version != settingsVersion {
+		t.Errorf("got version %d, want %d", settings.Version, settingsVersion)
+	}
+	if settings.Match.Map != "Mannfield" || len(settings.Favorites) != 1 {
+		t.Errorf("lost the settings of the file: %+v", settings)
+	}
+
+	defaults := DefaultSettings()
+	if settings.Wine != defaults.Wine || settings.RocketHost != defaults.RocketHost {
+		t.Errorf("got wine %+v and RocketHost %+v, want the defaults", settings.Wine, settings.RocketHost)
+	}
+	if settings.Match.GameMode != defaults.Match.GameMode || settings.Supervisor.MaxRestarts != defaults.Supervisor.MaxRestarts {
+		t.Errorf("missing values weren't filled in: %+v", settings)
+	}
+}
+
+func TestParseSettingsVersions(t *testing.T) {
+	for _, test := range []struct {
+		data string
+		want string
+	}{
+		{"version = 0\n", "invalid settings version"},
+		{"version = 2\n", "newer GUI"},
+	} {
+		_, err := ParseSettings([]byte(test.data))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%q: got %v, want an error about %q", test.data, err, test.want)
+		}
+	}
+}
+
+func TestSettingsStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	store := LoadSettingsStore(path)
+
+	err := store.Update(func(s *Settings) {
+		s.Supervisor.RestartOnCrash = true
+		s.Match.Mutators = map[string]int{"matchLength": 2}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := LoadSettingsStore(path).Get()
+	if !loaded.Supervisor.RestartOnCrash || loaded.Match.Mutators["matchLength"] != 2 {
+		t.Errorf("the settings weren't saved: %+v", loaded)
+	}
+
+	err = store.Update(func(s *Settings) {
+		s.Supervisor.MaxRestarts = -1
+	})
+	if err == nil {
+		t.Error("saved invalid supervisor settings")
+	}
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	rlbot "github.com/RLBot/go-interface"
+	"github.com/RLBot/go-interface/flat"
+)
+
+//go:embed showcases/*.toml
+var builtinShowcases embed.FS
+
+// ShowcasePhysics is a partial physics state, unset fields are left alone
+type ShowcasePhysics struct {
+	Location *[3]float32 `toml:"location" json:"location"`
+	// pitch, yaw, roll
+	Rotation        *[3]float32 `toml:"rotation" json:"rotation"`
+	Velocity        *[3]float32 `toml:"velocity" json:"velocity"`
+	AngularVelocity *[3]float32 `toml:"angular_velocity" json:"angularVelocity"`
+}
+
+type ShowcaseController struct {
+	Throttle  float32 `toml:"throttle" json:"throttle"`
+	Steer     float32 `toml:"steer" json:"steer"`
+	Pitch     float32 `toml:"pitch" json:"pitch"`
+	Yaw       float32 `toml:"yaw" json:"yaw"`
+	Roll      float32 `toml:"roll" json:"roll"`
+	Jump      bool    `toml:"jump" json:"jump"`
+	Boost     bool    `toml:"boost" json:"boost"`
+	Handbrake bool    `toml:"handbrake" json:"handbrake"`
+}
+
+type ShowcaseKeyframe struct {
+	// Seconds since the start of the scene
+	Time        float32             `toml:"time" json:"time"`
+	Car         *ShowcasePhysics    `toml:"car" json:"car"`
+	Ball        *ShowcasePhysics    `toml:"ball" json:"ball"`
+	BoostAmount *float32            `toml:"boost_amount" json:"boostAmount"`
+	Controller  *ShowcaseController `toml:"controller" json:"controller"`
+	// Apply the physics of this keyframe every tick until the next one
+	Hold bool `toml:"hold" json:"hold"`
+	// Shown to the user when the keyframe is reached
+	Camera string `toml:"camera" json:"camera"`
+}
+
+type ShowcaseScene struct {
+	// File name without the extension
+	Id          string `toml:"-" json:"id"`
+	Name        string `toml:"name" json:"name"`
+	Description string `toml:"description" json:"description"`
+	// Positions are for the blue team and get rotated for orange
+	Mirror bool `toml:"mirror" json:"mirror"`
+	// Shown to the user when the scene starts
+	Camera string `toml:"camera" json:"camera"`
+	// Seconds until the scene starts over, 0 plays it once
+	Loop      float32            `toml:"loop" json:"loop"`
+	Keyframes []ShowcaseKeyframe `toml:"keyframes" json:"keyframes"`
+}
+
+func ParseShowcase(id string, data string) (ShowcaseScene, error) {
+	scene := ShowcaseScene{Id: id}
+	if _, err := toml.Decode(data, &scene); err != nil {
+		return scene, err
+	}
+
+	if len(scene.Keyframes) == 0 {
+		return scene, errors.New("showcase " + id + " has no keyframes")
+	}
+	if scene.Name == "" {
+		scene.Name = id
+	}
+
+	sort.SliceStable(scene.Keyframes, func(i, j int) bool {
+		return scene.Keyframes[i].Time < scene.Keyframes[j].Time
+	})
+
+	return scene, nil
+}
+
+func readShowcases(fsys fs.FS, scenes map[string]ShowcaseScene) {
+	paths, _ := fs.Glob(fsys, "*.toml")
+	for _, path := range paths {
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			continue
+		}
+
+		id := strings.TrimSuffix(path, ".toml")
+		scene, err := ParseShowcase(id, string(data))
+		if err != nil {
+			println("WARN: skipping showcase " + path + ": " + err.Error())
+			continue
+		}
+		scenes[id] = scene
+	}
+}
+
+// LoadShowcases reads the built-in scenes and the ones in userDir,
+// which replace built-in scenes with the same file name
+func LoadShowcases(userDir string) map[string]ShowcaseScene {
+	scenes := map[string]ShowcaseScene{}
+
+	builtin, _ := fs.Sub(builtinShowcases, "showcases")
+	readShowcases(builtin, scenes)
+	readShowcases(os.DirFS(userDir), scenes)
+
+	return scenes
+}
+
+func mirrorVector(v [3]float32) [3]float32 {
+	return [3]float32{-v[0], -v[1], v[2]}
+}
+
+func (p ShowcasePhysics) toDesired(mirror bool) *flat.DesiredPhysicsT {
+	physics := &flat.DesiredPhysicsT{}
+
+	// rotating the field by 180 degrees swaps the blue and orange sides
+	if p.Location != nil {
+		v := *p.Location
+		if mirror {
+			v = mirrorVector(v)
+		}
+		physics.Location = Vector3P(v[0], v[1], v[2])
+	}
+	if p.Rotation != nil {
+		r := *p.Rotation
+		if mirror {
+			r[1] += math.Pi
+		}
+		physics.Rotation = RotatorP(r[0], r[1], r[2])
+	}
+	if p.Velocity != nil {
+		v := *p.Velocity
+		if mirror {
+			v = mirrorVector(v)
+		}
+		physics.Velocity = Vector3P(v[0], v[1], v[2])
+	}
+	if p.AngularVelocity != nil {
+		v := *p.AngularVelocity
+		if mirror {
+			v = mirrorVector(v)
+		}
+		physics.AngularVelocity = Vector3P(v[0], v[1], v[2])
+	}
+
+	return physics
+}
+
+func (c ShowcaseController) toFlat() *flat.ControllerStateT {
+	return &flat.ControllerStateT{
+		Throttle:  c.Throttle,
+		Steer:     c.Steer,
+		Pitch:     c.Pitch,
+		Yaw:       c.Yaw,
+		Roll:      c.Roll,
+		Jump:      c.Jump,
+		Boost:     c.Boost,
+		Handbrake: c.Handbrake,
+	}
+}
+
+func (k ShowcaseKeyframe) gameState(mirror bool) *flat.DesiredGameStateT {
+	state := &flat.DesiredGameStateT{}
+
+	if k.Car != nil || k.BoostAmount != nil {
+		car := &flat.DesiredCarStateT{}
+		if k.Car != nil {
+			car.Physics = k.Car.toDesired(mirror)
+		}
+		if k.BoostAmount != nil {
+			car.BoostAmount = Float(*k.BoostAmount)
+		}
+		state.CarStates = []*flat.DesiredCarStateT{car}
+	}
+
+	if k.Ball != nil {
+		state.BallStates = []*flat.DesiredBallStateT{{Physics: k.Ball.toDesired(mirror)}}
+	}
+
+	return state
+}
+
+// ShowcasePlayer plays a scene on the showcase car, the first player of the match
+type ShowcasePlayer struct {
+	conn *rlbot.RLBotConnection
+	// a cancelled showcase disconnects from another goroutine
+	connMu *sync.Mutex
+	scene  ShowcaseScene
+	// called with the camera hints of the scene
+	onCamera func(string)
+
+	started bool
+	mirror  bool
+	start   float32
+	next    int
+	held    *ShowcaseKeyframe
+}
+
+func (p *ShowcasePlayer) apply(keyframe *ShowcaseKeyframe) {
+	state := keyframe.gameState(p.mirror)
+	p.connMu.Lock()
+	defer p.connMu.Unlock()
+
+	if len(state.CarStates) > 0 || len(state.BallStates) > 0 {
+		p.conn.SendPacket(state)
+	}
+
+	controller := keyframe.Controller
+	if controller == nil && p.next == 0 {
+		// don't keep the inputs of the previous scene
+		controller = &ShowcaseController{}
+	}
+	if controller != nil {
+		p.conn.SendPacket(&flat.PlayerInputT{
+			PlayerIndex:     0,
+			ControllerState: controller.toFlat(),
+		})
+	}
+
+	if keyframe.Camera != "" && p.onCamera != nil {
+		p.onCamera(keyframe.Camera)
+	}
+
+	if keyframe.Hold {
+		p.held = keyframe
+	} else {
+		p.held = nil
+	}
+}
+
+// tick advances the timeline to the time of packet and returns false once the scene is over
+func (p *ShowcasePlayer) tick(packet *flat.GamePacketT) bool {
+	now := packet.MatchInfo.SecondsElapsed
+	if !p.started {
+		p.started = true
+		p.start = now
+		if len(packet.Players) > 0 {
+			p.mirror = p.scene.Mirror && packet.Players[0].Team == 1
+		}
+		if p.scene.Camera != "" && p.onCamera != nil {
+			p.onCamera(p.scene.Camera)
+		}
+	}
+
+	elapsed := now - p.start
+	if p.scene.Loop > 0 && elapsed >= p.scene.Loop {
+		p.start = now
+		p.next = 0
+		p.held = nil
+		elapsed = 0
+	}
+
+	applied := false
+	for p.next < len(p.scene.Keyframes) && p.scene.Keyframes[p.next].Time <= elapsed {
+		p.apply(&p.scene.Keyframes[p.next])
+		p.next++
+		applied = true
+	}
+
+	if !applied && p.held != nil {
+		p.connMu.Lock()
+		p.conn.SendPacket(p.held.gameState(p.mirror))
+		p.connMu.Unlock()
+	}
+
+	finished := p.next == len(p.scene.Keyframes)
+	return !finished || p.held != nil || p.scene.Loop > 0
+}
+
+// PlayShowcase plays scene for a car of team until it's over, ctx is cancelled or the match ends
+func PlayShowcase(ctx context.Context, rlbotAddress string, scene ShowcaseScene, team uint32, onCamera func(string)) error {
+	conn, err := rlbot.Connect(rlbotAddress)
+	if err != nil {
+		return err
+	}
+
+	var connMu sync.Mutex
+	disconnect := func() {
+		connMu.Lock()
+		defer connMu.Unlock()
+		conn.SendPacket(&flat.DisconnectSignalT{})
+	}
+
+	// RecvPacket can't be interrupted, so make RLBotServer close the
+	// connection instead, which also works when no match is running
+	stopDisconnect := context.AfterFunc(ctx, disconnect)
+	defer func() {
+		if stopDisconnect() {
+			disconnect()
+		}
+	}()
+
+	connMu.Lock()
+	conn.SendPacket(&flat.ConnectionSettingsT{
+		AgentId:              "",
+		WantsBallPredictions: false,
+		WantsComms:           false,
+		CloseBetweenMatches:  true,
+	})
+	conn.SendPacket(&flat.InitCompleteT{})
+	connMu.Unlock()
+
+	player := ShowcasePlayer{
+		conn:     &conn,
+		connMu:   &connMu,
+		scene:    scene,
+		onCamera: onCamera,
+		mirror:   scene.Mirror && team == 1,
+	}
+	for ctx.Err() == nil {
+		packet, err := conn.RecvPacket()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		switch packet := packet.Value.(type) {
+		case *flat.DisconnectSignalT:
+			return nil
+		case *flat.GamePacketT:
+			if packet.MatchInfo == nil {
+				continue
+			}
+			if !player.tick(packet) {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *App) showcasesDir() string {
+	return filepath.Join(a.GetDefaultPath(), "showcases")
+}
+
+// GetShowcases lists the built-in showcase scenes and the ones in the GUI's showcases folder
+func (a *App) GetShowcases() []ShowcaseScene {
+	scenes := []ShowcaseScene{}
+	for _, scene := range LoadShowcases(a.showcasesDir()) {
+		scenes = append(scenes, scene)
+	}
+
+	sort.Slice(scenes, func(i, j int) bool {
+		return scenes[i].Name < scenes[j].Name
+	})
+
+	return scenes
+}
+
+// ShowcaseManager runs at most one showcase at a time
+type ShowcaseManager struct {
+	// held for all of Start and Stop, so that a showcase is never replaced without being stopped
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	// closed once the current showcase stopped
+	done chan struct{}
+}
+
+func NewShowcaseManager() *ShowcaseManager {
+	return &ShowcaseManager{}
+}
+
+// Start stops the current showcase and plays scene instead
+func (m *ShowcaseManager) Start(rlbotAddress string, scene ShowcaseScene, team uint32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	m.cancel = cancel
+	m.done = done
+
+	go func() {
+		defer close(done)
+		defer cancel()
+
+		err := PlayShowcase(ctx, rlbotAddress, scene, team, func(hint string) {
+			emitEvent("showcase-camera", hint)
+		})
+		if err != nil {
+			println("WARN: showcase " + scene.Id + " stopped: " + err.Error())
+		}
+	}()
+}
+
+// Stop cancels the current showcase and waits for it to disconnect
+func (m *ShowcaseManager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stop()
+}
+
+// stop is Stop for when m.mu is held
+func (m *ShowcaseManager) stop() {
+	cancel, done := m.cancel, m.done
+	m.cancel, m.done = nil, nil
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		println("WARN: timed out waiting for the showcase to stop")
+	}
+}
+
+// SetShowcaseType plays the showcase scene with the id showcaseType on the preview car,
+// replacing the previous showcase
+func (a *App) SetShowcaseType(showcaseType string, team uint32) error {
+	scene, ok := LoadShowcases(a.showcasesDir())[showcaseType]
+	if !ok {
+		return errors.New("unknown showcase: " + showcaseType)
+	}
+
+	a.showcases.Start(a.rlbotAddress(), scene, team)
+	return nil
+}
+
+func (a *App) StopShowcase() {
+	a.showcases.Stop()
+}
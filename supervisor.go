@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+)
+
+type ResourceLimits struct {
+	// Maximum memory of an agent in megabytes, 0 for no limit
+	MaxMemoryMB uint64 `toml:"max_memory_mb" json:"maxMemoryMb"`
+	// Maximum CPU usage of an agent, 100 being one full core, 0 for no limit
+	MaxCpuPercent uint32 `toml:"max_cpu_percent" json:"maxCpuPercent"`
+}
+
+func (l ResourceLimits) Enabled() bool {
+	return l.MaxMemoryMB != 0 || l.MaxCpuPercent != 0
+}
+
+type SupervisorSettings struct {
+	// Restart agents that exit while the match is running
+	RestartOnCrash bool `toml:"restart_on_crash" json:"restartOnCrash"`
+	// How many times an agent may be restarted during one match
+	MaxRestarts int `toml:"max_restarts" json:"maxRestarts"`
+	// Delay before the first restart, doubled after every restart
+	BackoffMs int            `toml:"backoff_ms" json:"backoffMs"`
+	Limits    ResourceLimits `toml:"limits" json:"limits"`
+	// Extensions of the files that make auto reload restart an agent, empty for every file
+	WatchExtensions []string `toml:"watch_extensions" json:"watchExtensions"`
+	// Names of the files and directories auto reload doesn't look at, may contain wildcards
+	WatchIgnore []string `toml:"watch_ignore" json:"watchIgnore"`
+}
+
+func DefaultSupervisorSettings() SupervisorSettings {
+	return SupervisorSettings{
+		RestartOnCrash: false,
+		MaxRestarts:    3,
+		BackoffMs:      1000,
+		WatchExtensions: []string{
+			".py", ".cs", ".java", ".kt", ".rs", ".go", ".js", ".ts", ".lua",
+			".c", ".cc", ".cpp", ".h", ".hpp", ".toml", ".cfg", ".ini",
+		},
+		// build output, dependencies and caches
+		WatchIgnore: []string{
+			".git", "venv", ".venv", "node_modules", "__pycache__",
+			"bin", "obj", "target", "build", "dist", "*.log",
+		},
+	}
+}
+
+func (s SupervisorSettings) Validate() error {
+	if s.MaxRestarts < 0 {
+		return errors.New("max restarts can't be negative")
+	}
+
+	if s.BackoffMs < 0 {
+		return errors.New("backoff can't be negative")
+	}
+
+	if s.Limits.MaxCpuPercent > 100*1024 {
+		return errors.New("cpu limit is unreasonably large")
+	}
+
+	for _, pattern := range s.WatchIgnore {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return errors.New("invalid auto reload ignore pattern: " + pattern)
+		}
+	}
+
+	return nil
+}
+
+// backoff is how long to wait before the given restart (starting at 0)
+func (s SupervisorSettings) backoff(restart int) time.Duration {
+	const maxBackoff = 30 * time.Second
+
+	delay := time.Duration(s.BackoffMs) * time.Millisecond
+	for range restart {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return delay
+}
+
+func (a *App) GetSupervisorSettings() SupervisorSettings {
+	return a.settings.Get().Supervisor
+}
+
+func (a *App) SetSupervisorSettings(settings SupervisorSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	err := a.settings.Update(func(s *Settings) {
+		s.Supervisor = settings
+	})
+	if err != nil {
+		return err
+	}
+
+	a.applySettings(a.settings.Get())
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+const (
+	// The bot has a run command for the current platform
+	RunnerNative = "native"
+	// The bot's Windows run command is wrapped with wine
+	RunnerWine = "wine"
+	// The bot's Windows run command is wrapped with proton
+	RunnerProton = "proton"
+	// The bot can't be started by the GUI on this platform,
+	// RLBotServer gets an empty run command
+	RunnerNone = "none"
+)
+
+type WineSettings struct {
+	// Wrap the Windows run command of bots without a Linux run command
+	Enabled bool `toml:"enabled" json:"enabled"`
+	// Either "wine" or "proton"
+	Runner string `toml:"runner" json:"runner"`
+	// Path to the wine binary or proton script, wine is looked up in PATH if empty
+	Path string `toml:"path" json:"path"`
+	// WINEPREFIX for wine, STEAM_COMPAT_DATA_PATH for proton
+	Prefix string `toml:"prefix" json:"prefix"`
+}
+
+type AgentRunner struct {
+	// One of RunnerNative, RunnerWine, RunnerProton or RunnerNone
+	Kind string `json:"kind"`
+	// The command that will be sent to RLBotServer
+	Command string `json:"command"`
+}
+
+type WineInstall struct {
+	Runner  string `json:"runner"`
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+// shellQuote quotes s so that sh treats it as a single word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (w WineSettings) binary() string {
+	if w.Path != "" {
+		return w.Path
+	}
+
+	return w.Runner
+}
+
+// Wrap turns a Windows run command into one that can be started on Linux
+func (w WineSettings) Wrap(runCommand string) string {
+	env := []string{"env"}
+	var command []string
+
+	switch w.Runner {
+	case RunnerProton:
+		if w.Prefix != "" {
+			env = append(env, "STEAM_COMPAT_DATA_PATH="+shellQuote(w.Prefix))
+		}
+		env = append(env, "STEAM_COMPAT_CLIENT_INSTALL_PATH="+shellQuote(steamRoot()))
+		command = []string{shellQuote(w.binary()), "run", "cmd", "/c", shellQuote(runCommand)}
+	default:
+		if w.Prefix != "" {
+			env = append(env, "WINEPREFIX="+shellQuote(w.Prefix))
+		}
+		command = []string{shellQuote(w.binary()), "cmd", "/c", shellQuote(runCommand)}
+	}
+
+	return strings.Join(append(env, command...), " ")
+}
+
+// ResolveRunner decides how the bot described by settings will be started on this platform
+func (w WineSettings) ResolveRunner(settings BotSettings) AgentRunner {
+	if runtime.GOOS == "windows" {
+		return AgentRunner{RunnerNative, settings.RunCommand}
+	}
+
+	if settings.RunCommandLinux != "" {
+		return AgentRunner{RunnerNative, settings.RunCommandLinux}
+	}
+
+	if settings.RunCommand == "" || !w.Enabled {
+		return AgentRunner{RunnerNone, ""}
+	}
+
+	return AgentRunner{w.Runner, w.Wrap(settings.RunCommand)}
+}
+
+func (w WineSettings) Validate() error {
+	if w.Runner != RunnerWine && w.Runner != RunnerProton {
+		return errors.New("unknown runner: " + w.Runner)
+	}
+
+	if !w.Enabled {
+		return nil
+	}
+
+	if w.Path == "" && w.Runner == RunnerProton {
+		return errors.New("proton requires the path to the proton script")
+	}
+	if w.Prefix == "" && w.Runner == RunnerProton {
+		// proton won't start without STEAM_COMPAT_DATA_PATH
+		return errors.New("proton requires a prefix")
+	}
+
+	if _, err := exec.LookPath(w.binary()); err != nil {
+		return err
+	}
+
+	if w.Prefix != "" {
+		if info, err := os.Stat(w.Prefix); err == nil && !info.IsDir() {
+			return errors.New("prefix is not a directory: " + w.Prefix)
+		}
+	}
+
+	return nil
+}
+
+func steamRoot() string {
+	home := os.Getenv("HOME")
+
+	candidates := []string{
+		filepath.Join(home, ".steam/steam"),
+		filepath.Join(home, ".local/share/Steam"),
+		filepath.Join(home, ".var/app/com.valvesoftware.Steam/.local/share/Steam"),
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+
+	return candidates[0]
+}
+
+func wineVersion(path string) string {
+	output, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+func (a *App) GetWineSettings() WineSettings {
+	return a.settings.Get().Wine
+}
+
+func (a *App) SetWineSettings(settings WineSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	return a.settings.Update(func(s *Settings) {
+		s.Wine = settings
+	})
+}
+
+// DetectWineInstalls lists the wine and proton installs found on this machine
+func (a *App) DetectWineInstalls() []WineInstall {
+	installs := []WineInstall{}
+	if runtime.GOOS == "windows" {
+		return installs
+	}
+
+	if path, err := exec.LookPath("wine"); err == nil {
+		installs = append(installs, WineInstall{RunnerWine, path, wineVersion(path)})
+	}
+
+	root := steamRoot()
+	patterns := []string{
+		filepath.Join(root, "steamapps/common/Proton*/proton"),
+		filepath.Join(root, "compatibilitytools.d/*/proton"),
+	}
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+
+		sort.Strings(matches)
+		for _, match := range matches {
+			installs = append(installs, WineInstall{
+				Runner:  RunnerProton,
+				Path:    match,
+				Version: filepath.Base(filepath.Dir(match)),
+			})
+		}
+	}
+
+	return installs
+}